@@ -0,0 +1,180 @@
+package converter
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AllowedArchiveExtensions lists container extensions validateFile accepts
+// in addition to a save/ROM's own extensions; the container is expected to
+// hold exactly one entry matching the allowed extensions for the file it
+// replaces.
+var AllowedArchiveExtensions = map[string]bool{
+	".zip": true,
+	".7z":  true,
+	".gz":  true,
+}
+
+var (
+	zipMagic    = []byte("PK\x03\x04")
+	gzipMagic   = []byte{0x1f, 0x8b}
+	sevenZMagic = []byte{'7', 'z', 0xBC, 0xAF, 0x27, 0x1C}
+)
+
+// sniffContainer identifies path's container format from its magic bytes,
+// independent of its extension. It returns "" when path isn't a recognized
+// archive/compression container.
+func sniffContainer(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("Error opening '%s' to detect container format: %w", path, err)
+	}
+	defer f.Close()
+
+	head := make([]byte, len(sevenZMagic))
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", fmt.Errorf("Error reading '%s' to detect container format: %w", path, err)
+	}
+	head = head[:n]
+
+	switch {
+	case bytes.HasPrefix(head, zipMagic):
+		return "zip", nil
+	case bytes.HasPrefix(head, gzipMagic):
+		return "gzip", nil
+	case bytes.Equal(head, sevenZMagic):
+		return "7z", nil
+	default:
+		return "", nil
+	}
+}
+
+// resolveInput validates path and, when it is a recognized archive
+// container, transparently extracts the first entry matching
+// allowedExtensions to a temp file. It returns the path to operate on (path
+// itself, or the extracted temp file), that file's extension, and a
+// cleanup func that removes any temp file created. Callers must always call
+// cleanup, even on error.
+func resolveInput(path string, maxSize int64, allowedExtensions map[string]bool, fileType string) (resolvedPath string, ext string, cleanup func(), err error) {
+	noop := func() {}
+
+	if err := validateFile(path, false, maxSize, allowedExtensions, fileType); err != nil {
+		return "", "", noop, err
+	}
+
+	kind, err := sniffContainer(path)
+	if err != nil {
+		return "", "", noop, err
+	}
+	if kind == "" {
+		return path, filepath.Ext(path), noop, nil
+	}
+
+	extractedPath, extractedExt, err := extractArchiveMember(path, kind, allowedExtensions)
+	if err != nil {
+		return "", "", noop, err
+	}
+	cleanup = func() { os.Remove(extractedPath) }
+
+	if err := validateFile(extractedPath, false, maxSize, allowedExtensions, fileType); err != nil {
+		cleanup()
+		return "", "", noop, err
+	}
+
+	return extractedPath, extractedExt, cleanup, nil
+}
+
+// extractArchiveMember extracts the first entry of the given container kind
+// whose extension is in allowedExtensions to a new temp file, returning its
+// path and extension.
+func extractArchiveMember(path, kind string, allowedExtensions map[string]bool) (string, string, error) {
+	switch kind {
+	case "zip":
+		return extractZipMember(path, allowedExtensions)
+	case "gzip":
+		return extractGzipMember(path, allowedExtensions)
+	case "7z":
+		return "", "", fmt.Errorf("Error: '%s' is a 7z archive, which Go's standard library cannot extract; please extract it manually first", path)
+	default:
+		return "", "", fmt.Errorf("Error: '%s' has an unrecognized container format", path)
+	}
+}
+
+func extractZipMember(path string, allowedExtensions map[string]bool) (string, string, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return "", "", fmt.Errorf("Error opening zip archive '%s': %w", path, err)
+	}
+	defer r.Close()
+
+	for _, entry := range r.File {
+		ext := filepath.Ext(entry.Name)
+		if !allowedExtensions[ext] {
+			continue
+		}
+
+		rc, err := entry.Open()
+		if err != nil {
+			return "", "", fmt.Errorf("Error opening '%s' inside zip archive '%s': %w", entry.Name, path, err)
+		}
+		defer rc.Close()
+
+		tempPath, err := writeTempFile(ext, rc)
+		if err != nil {
+			return "", "", err
+		}
+		return tempPath, ext, nil
+	}
+
+	return "", "", fmt.Errorf("Error: zip archive '%s' contains no entry with an allowed extension", path)
+}
+
+func extractGzipMember(path string, allowedExtensions map[string]bool) (string, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", "", fmt.Errorf("Error opening gzip file '%s': %w", path, err)
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return "", "", fmt.Errorf("Error opening gzip stream '%s': %w", path, err)
+	}
+	defer gr.Close()
+
+	ext := filepath.Ext(strings.TrimSuffix(filepath.Base(path), ".gz"))
+	if gr.Name != "" {
+		ext = filepath.Ext(gr.Name)
+	}
+	if !allowedExtensions[ext] {
+		return "", "", fmt.Errorf("Error: gzip container '%s' does not expand to an allowed extension (got '%s')", path, ext)
+	}
+
+	tempPath, err := writeTempFile(ext, gr)
+	if err != nil {
+		return "", "", err
+	}
+	return tempPath, ext, nil
+}
+
+// writeTempFile copies r into a new temp file with the given extension and returns its path.
+func writeTempFile(ext string, r io.Reader) (string, error) {
+	tmp, err := os.CreateTemp("", "save-converter-*"+ext)
+	if err != nil {
+		return "", fmt.Errorf("Error creating temp file for archive entry: %w", err)
+	}
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("Error extracting archive entry: %w", err)
+	}
+	return tmp.Name(), nil
+}
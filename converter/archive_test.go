@@ -0,0 +1,144 @@
+package converter
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeZip(t *testing.T, dir, zipName, memberName string, content []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, zipName)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	w, err := zw.Create(memberName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func writeGzip(t *testing.T, dir, gzName, innerName string, content []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, gzName)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	gw := gzip.NewWriter(f)
+	gw.Name = innerName
+	if _, err := gw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestResolveInputZip confirms resolveInput extracts a zip member's
+// decompressed payload - not the zip's own bytes - and reports its real
+// extension, and that cleanup removes the extracted temp file.
+func TestResolveInputZip(t *testing.T) {
+	dir := t.TempDir()
+	payload := buildZ64Payload("Zipped Game", 256)
+	zipPath := writeZip(t, dir, "rom.zip", "game.z64", payload)
+
+	resolved, ext, cleanup, err := resolveInput(zipPath, MaxRomFileSize, AllowedRomExtensions, "ROM:")
+	if err != nil {
+		t.Fatalf("resolveInput: %v", err)
+	}
+	if ext != ".z64" {
+		t.Errorf("ext = %q, want .z64", ext)
+	}
+
+	got, err := os.ReadFile(resolved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Error("resolved file content does not match the zip member's decompressed payload")
+	}
+
+	cleanup()
+	if _, err := os.Stat(resolved); !os.IsNotExist(err) {
+		t.Error("cleanup did not remove the extracted temp file")
+	}
+}
+
+// TestResolveInputGzip is TestResolveInputZip's gzip counterpart.
+func TestResolveInputGzip(t *testing.T) {
+	dir := t.TempDir()
+	payload := []byte{0x01, 0x02, 0x03, 0x04}
+	gzPath := writeGzip(t, dir, "save.sra.gz", "save.sra", payload)
+
+	resolved, ext, cleanup, err := resolveInput(gzPath, MaxSaveFileSize, AllowedSaveExtensions, "Save:")
+	defer cleanup()
+	if err != nil {
+		t.Fatalf("resolveInput: %v", err)
+	}
+	if ext != ".sra" {
+		t.Errorf("ext = %q, want .sra", ext)
+	}
+
+	got, err := os.ReadFile(resolved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Error("resolved file content does not match the gzip member's decompressed payload")
+	}
+}
+
+// TestConvertThroughZippedRom confirms the full Convert pipeline identifies
+// and titles from a zipped ROM's decompressed payload rather than the zip
+// container's own bytes.
+func TestConvertThroughZippedRom(t *testing.T) {
+	dir := t.TempDir()
+	romPayload := buildZ64Payload("Zip Convert Game", 4096)
+	zipPath := writeZip(t, dir, "rom.zip", "game.z64", romPayload)
+
+	saveData := []byte{0xAA, 0xBB, 0xCC, 0xDD}
+	saveFile := filepath.Join(dir, "save.eep")
+	if err := os.WriteFile(saveFile, saveData, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	outDir := filepath.Join(dir, "out")
+	if err := os.Mkdir(outDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	outFile, err := Convert(saveFile, zipPath, outDir)
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	if !strings.Contains(filepath.Base(outFile), "Zip Convert Game") {
+		t.Errorf("output filename %q does not carry the zipped ROM's title, suggesting it read the zip container instead of its payload", outFile)
+	}
+
+	got, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, saveData) {
+		t.Errorf("converted save content = % x, want % x", got, saveData)
+	}
+}
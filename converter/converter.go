@@ -0,0 +1,551 @@
+// Package converter holds the N64 save/ROM conversion logic shared by the
+// single-file and batch CLI modes: ROM identification/hashing, output
+// naming, and the byte-swap/trim/pad transforms that convert save files
+// between emulator profiles (to gopher64 by default).
+package converter
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lualona66/save-converter/emulator"
+	"github.com/lualona66/save-converter/romdb"
+)
+
+const (
+	MaxSaveFileSize = 256 * 1024       // 256KB
+	MaxRomFileSize  = 64 * 1024 * 1024 // 64MB
+	bootcodeSize    = 0x1000           // N64 header + bootstrap area, excludable from CRC32/MD5
+)
+
+var AllowedSaveExtensions = map[string]bool{
+	".eep":    true,
+	".eeprom": true,
+	".fla":    true,
+	".flash":  true,
+	".mpk":    true,
+	".pak":    true,
+	".ram":    true,
+	".sra":    true,
+}
+
+var AllowedRomExtensions = map[string]bool{
+	".z64": true,
+	".n64": true,
+	".v64": true,
+}
+
+var romFormats = map[string]string{
+	"z64": "\x80\x37\x12\x40", // Big-endian
+	"n64": "\x40\x12\x37\x80", // Little-endian
+	"v64": "\x37\x80\x40\x12", // Byte-swapped
+}
+
+// Options controls the emulator profiles and ROM-identification behavior
+// used by Convert and Plan. The zero value reproduces the tool's original
+// behavior: From is inferred per-extension (see emulator.DefaultSource), To
+// defaults to gopher64, and no DAT lookup is performed.
+type Options struct {
+	DAT             *romdb.DB
+	ExcludeBootcode bool
+	From            emulator.Profile
+	To              emulator.Profile
+}
+
+func (o Options) fromProfile(saveExt string) emulator.Profile {
+	if o.From != nil {
+		return o.From
+	}
+	return emulator.DefaultSource(saveExt)
+}
+
+func (o Options) toProfile() emulator.Profile {
+	if o.To != nil {
+		return o.To
+	}
+	gopher64, _ := emulator.Get("gopher64")
+	return gopher64
+}
+
+// Convert converts saveFile using romFile to derive the output name,
+// writes the result into outDir and returns the output file path.
+func Convert(saveFile, romFile, outDir string) (string, error) {
+	return ConvertWithOptions(saveFile, romFile, outDir, Options{})
+}
+
+// ConvertWithOptions is Convert with an explicit --from/--to emulator.Profile pair and DAT-based ROM identification options.
+// On success it also writes a conversion manifest (see Manifest) alongside the output file.
+func ConvertWithOptions(saveFile, romFile, outDir string, opts Options) (string, error) {
+	plan, err := planOutput(saveFile, romFile, outDir, opts)
+	defer plan.Cleanup()
+	if err != nil {
+		return "", err
+	}
+
+	kind, _ := emulator.KindForExtension(plan.SaveExt)
+	from, to := opts.fromProfile(plan.SaveExt), opts.toProfile()
+
+	var paddingBytes int64
+	if from.EndiannessFor(kind) != to.EndiannessFor(kind) {
+		if err := convertSaveFile(plan.ResolvedSave, plan.OutputFile); err != nil {
+			return "", err
+		}
+	} else if targetSize := to.TargetSize(kind); targetSize > 0 {
+		paddingBytes, err = copyFile(plan.ResolvedSave, plan.OutputFile, targetSize, emulator.MempakSize)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		if _, err := copyFile(plan.ResolvedSave, plan.OutputFile, 0, 0); err != nil {
+			return "", err
+		}
+	}
+
+	if err := writeManifestFor(saveFile, romFile, plan, paddingBytes); err != nil {
+		return "", err
+	}
+
+	return plan.OutputFile, nil
+}
+
+// Plan reports the output filename ConvertWithOptions would produce for
+// saveFile/romFile without writing anything, for --dry-run reporting.
+func Plan(saveFile, romFile, outDir string, opts Options) (string, error) {
+	plan, err := planOutput(saveFile, romFile, outDir, opts)
+	defer plan.Cleanup()
+	return plan.OutputFile, err
+}
+
+// planResult bundles everything planOutput resolves about a save/ROM pair:
+// the files to operate on, the output path, and the ROM provenance fields
+// that end up in the conversion manifest.
+type planResult struct {
+	ResolvedSave string
+	SaveExt      string
+	OutputFile   string
+	CleanTitle   string
+	RomFormat    string
+	RomSHA256    string
+	RomCRC32     string
+	RomMD5       string
+	Cleanup      func()
+}
+
+// planOutput resolves the inputs (transparently unpacking either from a
+// zip/gzip container), identifies the ROM and computes the output path,
+// without performing the save-file transform. The returned ResolvedSave is
+// the actual file to read the save data from (the original saveFile, or its
+// extracted payload) and must be left alone until Cleanup is called.
+func planOutput(saveFile, romFile, outDir string, opts Options) (planResult, error) {
+	resolvedSave, saveExt, cleanupSave, err := resolveInput(saveFile, MaxSaveFileSize, AllowedSaveExtensions, "Save:")
+	if err != nil {
+		return planResult{Cleanup: cleanupSave}, err
+	}
+
+	resolvedRom, _, cleanupRom, err := resolveInput(romFile, MaxRomFileSize, AllowedRomExtensions, "ROM:")
+	if err != nil {
+		cleanupSave()
+		return planResult{Cleanup: cleanupRom}, err
+	}
+
+	kind, ok := emulator.KindForExtension(saveExt)
+	if !ok {
+		cleanupSave()
+		cleanupRom()
+		return planResult{Cleanup: func() {}}, fmt.Errorf("Error: '%s' save extension has no known save kind", saveExt)
+	}
+
+	cleanTitle, romFormat, sha256Hex, crc32Hex, md5Hex, err := processRom(resolvedRom, opts.DAT, opts.ExcludeBootcode)
+	cleanupRom()
+	if err != nil {
+		cleanupSave()
+		return planResult{Cleanup: func() {}}, err
+	}
+
+	to := opts.toProfile()
+	outputExt := to.SaveExtension(kind)
+	outputFile := filepath.Join(outDir, to.OutputFilename(cleanTitle, sha256Hex, outputExt))
+
+	return planResult{
+		ResolvedSave: resolvedSave,
+		SaveExt:      saveExt,
+		OutputFile:   outputFile,
+		CleanTitle:   cleanTitle,
+		RomFormat:    romFormat,
+		RomSHA256:    sha256Hex,
+		RomCRC32:     crc32Hex,
+		RomMD5:       md5Hex,
+		Cleanup:      cleanupSave,
+	}, nil
+}
+
+// ExtractTitle reads just enough of romFile to return its header-derived
+// clean title, for matching saves to ROMs in batch mode without paying for
+// a full hash pass. romFile may be a zip/gzip container.
+func ExtractTitle(romFile string) (string, error) {
+	resolvedRom, _, cleanup, err := resolveInput(romFile, MaxRomFileSize, AllowedRomExtensions, "ROM:")
+	defer cleanup()
+	if err != nil {
+		return "", err
+	}
+
+	romHandle, err := os.Open(resolvedRom)
+	if err != nil {
+		return "", fmt.Errorf("Error opening ROM file: %w", err)
+	}
+	defer romHandle.Close()
+
+	rawHeader := make([]byte, 0x40)
+	if _, err := io.ReadFull(romHandle, rawHeader); err != nil {
+		return "", fmt.Errorf("Error reading ROM header: %w", err)
+	}
+
+	romMode, err := detectRomFormat(rawHeader[:4])
+	if err != nil {
+		return "", err
+	}
+
+	convHeader := convertToZ64(rawHeader, romMode)
+	return extractCleanTitle(convHeader), nil
+}
+
+// processRom extracts the ROM's name, detected format, and SHA256/CRC32/MD5
+// hashes. When dat is non-nil, the CRC32/MD5 are also looked up against it
+// and the DAT's canonical game name is preferred over the header-derived
+// title on a match.
+func processRom(romFile string, dat *romdb.DB, excludeBootcode bool) (cleanTitle, romFormat, sha256Hex, crc32Hex, md5Hex string, err error) {
+	romHandle, err := os.Open(romFile)
+	if err != nil {
+		return "", "", "", "", "", fmt.Errorf("Error opening ROM file: %w", err)
+	}
+	defer romHandle.Close()
+
+	rawHeader := make([]byte, 0x40)
+	if _, err := io.ReadFull(romHandle, rawHeader); err != nil {
+		return "", "", "", "", "", fmt.Errorf("Error reading ROM header: %w", err)
+	}
+
+	romMode, err := detectRomFormat(rawHeader[:4])
+	if err != nil {
+		return "", "", "", "", "", err
+	}
+
+	convHeader := convertToZ64(rawHeader, romMode)
+	cleanTitle = extractCleanTitle(convHeader)
+
+	sha256Hex, err = computeSHA256(romFile)
+	if err != nil {
+		return "", "", "", "", "", err
+	}
+
+	crc32Hex, md5Hex, err = computeCRC32MD5(romFile, romMode, excludeBootcode)
+	if err != nil {
+		return "", "", "", "", "", err
+	}
+
+	if dat != nil {
+		if game, ok := dat.Lookup(crc32Hex, md5Hex, ""); ok {
+			cleanTitle = sanitizeFilename(game.Name)
+		}
+	}
+
+	return cleanTitle, romMode, sha256Hex, crc32Hex, md5Hex, nil
+}
+
+// checks rom header and assigns correct rom format
+func detectRomFormat(magicBytes []byte) (string, error) {
+	for mode, magic := range romFormats {
+		if string(magicBytes) == magic {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("Error unsupported ROM format based on magic bytes: %x", magicBytes)
+}
+
+// convertToZ64 returns data in canonical big-endian (z64) byte order,
+// regardless of which of the three dump formats romMode says it's actually
+// in. No-Intro/Logiqx DAT hashes are computed against z64-order dumps, so
+// this is applied both to the header (to extract the title) and to the
+// whole file (to hash it for DAT lookup) before either is used.
+func convertToZ64(data []byte, romMode string) []byte {
+	converted := make([]byte, len(data))
+	switch romMode {
+	case "z64":
+		copy(converted, data)
+	case "n64":
+		for i := 0; i < len(data); i += 4 {
+			binary.BigEndian.PutUint32(converted[i:i+4], binary.LittleEndian.Uint32(data[i:i+4]))
+		}
+	case "v64":
+		for i := 0; i < len(data); i += 2 {
+			binary.BigEndian.PutUint16(converted[i:i+2], binary.LittleEndian.Uint16(data[i:i+2]))
+		}
+	}
+	return converted
+}
+
+// extracts rom name from converted header then removes special characters and trailing spaces
+func extractCleanTitle(convHeader []byte) string {
+	titleField := convHeader[0x20 : 0x20+20]
+	titleBytes := bytes.TrimRight(titleField, " ")
+	title := string(titleBytes)
+
+	var cleanTitleRunes strings.Builder
+	for _, r := range title {
+		if (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == ' ' {
+			cleanTitleRunes.WriteRune(r)
+		}
+	}
+	return cleanTitleRunes.String()
+}
+
+// compute SHA256 hash for rom file and return HEX string
+func computeSHA256(romFile string) (string, error) {
+	return computeFileSHA256(romFile, "ROM file")
+}
+
+// computeFileSHA256 hashes path and returns its SHA256 as an uppercase hex
+// string; fileType only customizes the error message on failure.
+func computeFileSHA256(path, fileType string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("Error opening %s for hashing: %w", fileType, err)
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.CopyBuffer(hasher, f, make([]byte, 4096)); err != nil {
+		return "", fmt.Errorf("Error computing SHA256 for %s: %w", fileType, err)
+	}
+	return fmt.Sprintf("%X", hasher.Sum(nil)), nil
+}
+
+// compute CRC32 and MD5 for the ROM file in a single pass, matching the
+// hashes No-Intro/Logiqx DAT files index games by: both are computed
+// against romFile's payload normalized to canonical z64 byte order (see
+// convertToZ64), since that's the byte order DAT hashes were built from,
+// regardless of whether romFile itself is z64, n64 or v64. When
+// excludeBootcode is set, the leading 0x1000-byte header/bootstrap area of
+// that normalized stream is skipped so the hash matches DAT conventions
+// that hash only the payload after it.
+func computeCRC32MD5(romFile, romMode string, excludeBootcode bool) (crc32Hex string, md5Hex string, err error) {
+	data, err := os.ReadFile(romFile)
+	if err != nil {
+		return "", "", fmt.Errorf("Error reading ROM file for hashing: %w", err)
+	}
+
+	normalized := convertToZ64(data, romMode)
+	if excludeBootcode {
+		if len(normalized) < bootcodeSize {
+			return "", "", fmt.Errorf("Error: ROM file is smaller than the bootcode area it was asked to exclude")
+		}
+		normalized = normalized[bootcodeSize:]
+	}
+
+	crcHasher := crc32.NewIEEE()
+	md5Hasher := md5.New()
+	if _, err := io.Copy(io.MultiWriter(crcHasher, md5Hasher), bytes.NewReader(normalized)); err != nil {
+		return "", "", fmt.Errorf("Error computing CRC32/MD5 for ROM file: %w", err)
+	}
+	return fmt.Sprintf("%08X", crcHasher.Sum32()), fmt.Sprintf("%X", md5Hasher.Sum(nil)), nil
+}
+
+// sanitizeFilename strips characters that are invalid in filenames on common
+// platforms, for use with DAT game names (which may contain characters such
+// as ':' or '/', e.g. "Game: Subtitle").
+func sanitizeFilename(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return -1
+		default:
+			return r
+		}
+	}, name)
+}
+
+// read the save file, processes it in 4-byte chunks (swapping endianness)
+func convertSaveFile(src, dst string) error {
+	fmt.Printf("\nConverting Data from: %s\n", src)
+	inFile, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("Error opening save file: %w", err)
+	}
+	defer inFile.Close()
+
+	outFile, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("Error creating output file: %w", err)
+	}
+	defer outFile.Close()
+
+	buf := make([]byte, 4)
+	for {
+		n, err := io.ReadFull(inFile, buf)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fmt.Errorf("Error reading save file: %w", err)
+		}
+		if n != 4 {
+			return fmt.Errorf("Error unexpected read size from save file: got %d bytes, expected 4", n)
+		}
+
+		binary.LittleEndian.PutUint32(buf, binary.BigEndian.Uint32(buf))
+
+		if _, err := outFile.Write(buf); err != nil {
+			return fmt.Errorf("Error writing to output file: %w", err)
+		}
+	}
+
+	return outFile.Sync()
+}
+
+// checks file size and trims or pads as needed then save file. targetSize of
+// 0 means copy verbatim. unitSize is the granularity a short source is first
+// padded up to before being tiled out to targetSize (e.g. a single 32KB
+// Controller Pak image tiled 4x into a 128KB fullmempak). It returns the
+// number of padding bytes added beyond the original source file's length,
+// for recording in the conversion manifest.
+func copyFile(src, dst string, targetSize, unitSize int64) (int64, error) {
+	fmt.Printf("\nCopying Data from: %s\n", src)
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, fmt.Errorf("Error opening source file: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return 0, fmt.Errorf("Error creating destination file: %w", err)
+	}
+	defer out.Close()
+
+	if targetSize == 0 {
+		_, err = io.Copy(out, in)
+		if err != nil {
+			return 0, fmt.Errorf("Error copying file: %w", err)
+		}
+		return 0, out.Sync()
+	}
+
+	fileData, err := io.ReadAll(in)
+	if err != nil {
+		return 0, fmt.Errorf("Error reading source file into memory: %w", err)
+	}
+	originalSize := int64(len(fileData))
+	fileSize := originalSize
+	if fileSize == 0 {
+		return 0, fmt.Errorf("Error source file is empty")
+	}
+
+	if fileSize > targetSize {
+		fileData = fileData[:targetSize]
+		fileSize = targetSize
+		fmt.Println("Source file was larger than the target size, trimmed to", targetSize, "bytes.")
+	}
+
+	if fileSize <= unitSize {
+		paddingSize := unitSize - fileSize
+		fmt.Println("File smaller than the unit size. Padding", paddingSize, "bytes to", unitSize)
+		padding := make([]byte, paddingSize) // Zero-filled padding
+		paddedFileData := make([]byte, 0, unitSize)
+		paddedFileData = append(paddedFileData, fileData...)
+		paddedFileData = append(paddedFileData, padding...)
+		fileData = paddedFileData
+		fileSize = int64(len(fileData))
+	}
+
+	_, err = out.Write(fileData)
+	if err != nil {
+		return 0, fmt.Errorf("Error writing initial data (trimmed/padded) to destination file: %w", err)
+	}
+
+	numCopies := 1 // Start with 1 because we already have base content
+	if targetSize > fileSize {
+		numCopies += int((targetSize - fileSize) / fileSize)
+	}
+
+	bytesToPad := 0
+	if targetSize > fileSize {
+		bytesToPad = int((targetSize - fileSize) % fileSize)
+	}
+
+	if numCopies > 1 {
+		for i := 1; i < numCopies; i++ {
+			_, err = out.Write(fileData)
+			if err != nil {
+				return 0, fmt.Errorf("Error writing repeated data to destination file: %w", err)
+			}
+		}
+	}
+
+	if bytesToPad > 0 {
+		fmt.Println("Padding", bytesToPad, "final bytes")
+		extrapadding := make([]byte, bytesToPad)
+		_, err = out.Write(extrapadding)
+		if err != nil {
+			return 0, fmt.Errorf("Error writing final padding data to destination file: %w", err)
+		}
+	}
+
+	if err := out.Sync(); err != nil {
+		return 0, err
+	}
+
+	paddingBytes := targetSize - originalSize
+	if paddingBytes < 0 {
+		paddingBytes = 0
+	}
+	return paddingBytes, nil
+}
+
+func validateFile(filePath string, isDir bool, maxSize int64, allowedExtensions map[string]bool, fileType string) error {
+	fmt.Println("Validating", fileType, filePath)
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		return fmt.Errorf("Error accessing %s file '%s': %w", fileType, filePath, err)
+	}
+	if fileInfo.IsDir() != isDir {
+		if isDir {
+			return fmt.Errorf("Error: '%s' is not a directory, expected a directory for %s file", filePath, fileType)
+		}
+		return fmt.Errorf("Error: '%s' is a directory, expected a file for %s file", filePath, fileType)
+	}
+	if !isDir && fileInfo.Size() > maxSize {
+		return fmt.Errorf("Error: %s file '%s' is too large (max size: %dKB)", fileType, filePath, maxSize/1024)
+	}
+	if !isDir && allowedExtensions != nil {
+		ext := filepath.Ext(filePath)
+		if allowedExtensions[ext] || AllowedArchiveExtensions[ext] {
+			return nil
+		}
+		// Extension didn't match, but the file might be a renamed or
+		// extensionless archive container; fall back to sniffing its
+		// magic bytes before rejecting it.
+		if kind, sniffErr := sniffContainer(filePath); sniffErr == nil && kind != "" {
+			return nil
+		}
+		allowedExts := strings.Join(getKeys(allowedExtensions), ", ")
+		return fmt.Errorf("Errorr: unsupported %s file extension '%s'. Allowed extensions are: %s", fileType, ext, allowedExts)
+	}
+	return nil
+}
+
+// getKeys helper function to extract keys from a map for error messages
+func getKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
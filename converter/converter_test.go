@@ -0,0 +1,138 @@
+package converter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lualona66/save-converter/emulator"
+)
+
+// writeTestRom writes a minimal valid z64 ROM (just enough header for
+// processRom: the z64 magic and a title field) to dir/name.
+func writeTestRom(t *testing.T, dir, name string) string {
+	t.Helper()
+	header := make([]byte, 0x40)
+	copy(header, romFormats["z64"])
+	copy(header[0x20:], "TESTGAME")
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, header, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// swapped4 returns src with every 4-byte word's byte order reversed, the
+// same transform convertSaveFile applies.
+func swapped4(src []byte) []byte {
+	out := make([]byte, len(src))
+	for i := 0; i < len(src); i += 4 {
+		out[i], out[i+1], out[i+2], out[i+3] = src[i+3], src[i+2], src[i+1], src[i]
+	}
+	return out
+}
+
+// TestDefaultSourceEndiannessByExtension pins the byte-level behavior
+// Convert's default (no --from/--to) path had before emulator.Profile
+// existed: only the short ares-style .fla/.sra extensions were
+// byte-swapped on their way to gopher64. The long project64-style
+// .flash/.ram/.eeprom/.mpk extensions were only renamed/padded, never
+// swapped, because they were assumed already gopher64-compatible -
+// DefaultSource must keep returning a profile whose endianness matches
+// gopher64's for those, or this default path silently starts scrambling
+// save data it previously passed through untouched.
+func TestDefaultSourceEndiannessByExtension(t *testing.T) {
+	saveData := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}
+
+	tests := []struct {
+		ext        string
+		wantSwap   bool
+		wasSwapped bool // behavior of the tool before Profile existed
+	}{
+		{ext: ".sra", wantSwap: true, wasSwapped: true},
+		{ext: ".fla", wantSwap: true, wasSwapped: true},
+		{ext: ".ram", wantSwap: false, wasSwapped: false},
+		{ext: ".flash", wantSwap: false, wasSwapped: false},
+		{ext: ".eep", wantSwap: false, wasSwapped: false},
+		{ext: ".eeprom", wantSwap: false, wasSwapped: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ext, func(t *testing.T) {
+			if tt.wantSwap != tt.wasSwapped {
+				t.Fatalf("test case itself is inconsistent for %s", tt.ext)
+			}
+
+			dir := t.TempDir()
+			romFile := writeTestRom(t, dir, "rom.z64")
+			saveFile := filepath.Join(dir, "save"+tt.ext)
+			if err := os.WriteFile(saveFile, saveData, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			outDir := filepath.Join(dir, "out")
+			if err := os.Mkdir(outDir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			outFile, err := Convert(saveFile, romFile, outDir)
+			if err != nil {
+				t.Fatalf("Convert(%s): %v", tt.ext, err)
+			}
+
+			got, err := os.ReadFile(outFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := saveData
+			if tt.wantSwap {
+				want = swapped4(saveData)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("Convert(%s) output = % x, want % x (wantSwap=%v)", tt.ext, got, want, tt.wantSwap)
+			}
+		})
+	}
+}
+
+// TestExplicitProject64SourceStillSwaps confirms that choosing project64 as
+// an explicit --from (rather than relying on DefaultSource) still applies
+// its declared BigEndian SRAM/FlashRAM convention against gopher64's
+// LittleEndian target - only the *default* inference changed, not what
+// --from project64 itself does.
+func TestExplicitProject64SourceStillSwaps(t *testing.T) {
+	saveData := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}
+
+	dir := t.TempDir()
+	romFile := writeTestRom(t, dir, "rom.z64")
+	saveFile := filepath.Join(dir, "save.ram")
+	if err := os.WriteFile(saveFile, saveData, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	outDir := filepath.Join(dir, "out")
+	if err := os.Mkdir(outDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	project64, ok := emulator.Get("project64")
+	if !ok {
+		t.Fatal("project64 profile not registered")
+	}
+
+	outFile, err := ConvertWithOptions(saveFile, romFile, outDir, Options{From: project64})
+	if err != nil {
+		t.Fatalf("ConvertWithOptions: %v", err)
+	}
+
+	got, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := swapped4(saveData); !bytes.Equal(got, want) {
+		t.Errorf("ConvertWithOptions(From: project64) output = % x, want % x", got, want)
+	}
+}
@@ -0,0 +1,107 @@
+package converter
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lualona66/save-converter/romdb"
+)
+
+// buildZ64Payload returns a deterministic z64-order ROM payload of size
+// bytes, with the z64 magic at offset 0 and title at its header offset.
+func buildZ64Payload(title string, size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	copy(data[0:4], romFormats["z64"])
+	copy(data[0x20:], title)
+	return data
+}
+
+// toN64 and toV64 convert a z64-order payload to the n64 (little-endian
+// words) and v64 (byte-swapped) dumps of the exact same ROM - the inverse of
+// convertToZ64's transforms for those modes.
+func toN64(z64 []byte) []byte {
+	out := make([]byte, len(z64))
+	for i := 0; i < len(z64); i += 4 {
+		binary.LittleEndian.PutUint32(out[i:i+4], binary.BigEndian.Uint32(z64[i:i+4]))
+	}
+	return out
+}
+
+func toV64(z64 []byte) []byte {
+	out := make([]byte, len(z64))
+	for i := 0; i < len(z64); i += 2 {
+		binary.LittleEndian.PutUint16(out[i:i+2], binary.BigEndian.Uint16(z64[i:i+2]))
+	}
+	return out
+}
+
+// TestProcessRomDATLookupAcrossByteOrders guards against CRC32/MD5 being
+// hashed in the ROM's on-disk byte order instead of the canonical z64 order
+// DAT files are built against: without normalization, a DAT entry built
+// from a z64 dump's hashes would never match the same ROM shipped as an n64
+// or v64 dump, silently defeating DAT-driven identification for two of the
+// three supported formats. It also exercises the DAT-driven title override
+// in processRom.
+func TestProcessRomDATLookupAcrossByteOrders(t *testing.T) {
+	dir := t.TempDir()
+	z64 := buildZ64Payload("Test Game", 4096)
+
+	crc32Hex := fmt.Sprintf("%08X", crc32.ChecksumIEEE(z64))
+	md5Hex := fmt.Sprintf("%X", md5.Sum(z64))
+	const datName = "Test Game (DAT)"
+
+	datXML := fmt.Sprintf(`<datafile>
+  <game name=%q>
+    <rom name="test.z64" size="%d" crc="%s" md5="%s" sha1=""/>
+  </game>
+</datafile>`, datName, len(z64), crc32Hex, md5Hex)
+
+	datPath := filepath.Join(dir, "test.dat")
+	if err := os.WriteFile(datPath, []byte(datXML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dat, err := romdb.Load(datPath)
+	if err != nil {
+		t.Fatalf("romdb.Load: %v", err)
+	}
+	if _, ok := dat.Lookup(crc32Hex, md5Hex, ""); !ok {
+		t.Fatalf("romdb.Lookup(%s, %s, \"\") found nothing right after loading the entry that carries those hashes", crc32Hex, md5Hex)
+	}
+
+	variants := map[string][]byte{
+		"z64": z64,
+		"n64": toN64(z64),
+		"v64": toV64(z64),
+	}
+	for mode, data := range variants {
+		t.Run(mode, func(t *testing.T) {
+			romFile := filepath.Join(dir, "rom."+mode)
+			if err := os.WriteFile(romFile, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			cleanTitle, gotMode, _, gotCRC, gotMD5, err := processRom(romFile, dat, false)
+			if err != nil {
+				t.Fatalf("processRom(%s): %v", mode, err)
+			}
+			if gotMode != mode {
+				t.Errorf("detected format = %q, want %q", gotMode, mode)
+			}
+			if gotCRC != crc32Hex || gotMD5 != md5Hex {
+				t.Errorf("processRom(%s) hashes = %s/%s, want %s/%s (CRC32/MD5 not normalized to z64 byte order)", mode, gotCRC, gotMD5, crc32Hex, md5Hex)
+			}
+			if cleanTitle != datName {
+				t.Errorf("processRom(%s) cleanTitle = %q, want DAT-driven override %q", mode, cleanTitle, datName)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Version is the save-converter build identifier recorded in conversion manifests.
+const Version = "1.0.0"
+
+// Manifest is the JSON sidecar (<output>.json) ConvertWithOptions writes
+// next to every converted file, auditing exactly what it was made from so a
+// later Verify (`save-converter verify <output>`) can confirm the output
+// hasn't silently changed or been corrupted by truncation/padding bugs.
+type Manifest struct {
+	ToolVersion  string `json:"tool_version"`
+	SourceFile   string `json:"source_file"`
+	SourceSHA256 string `json:"source_sha256"`
+	RomFile      string `json:"rom_file"`
+	RomFormat    string `json:"rom_format"`
+	RomSHA256    string `json:"rom_sha256"`
+	RomCRC32     string `json:"rom_crc32"`
+	RomMD5       string `json:"rom_md5"`
+	CleanTitle   string `json:"clean_title"`
+	OutputFile   string `json:"output_file"`
+	OutputSHA256 string `json:"output_sha256"`
+	TargetSize   int64  `json:"target_size"`
+	PaddingBytes int64  `json:"padding_bytes"`
+}
+
+func manifestPath(outputFile string) string { return outputFile + ".json" }
+func sidecarPath(outputFile string) string  { return outputFile + ".sha256" }
+
+// writeManifestFor hashes plan's resolved save and written output, and
+// writes the resulting Manifest alongside plan.OutputFile.
+func writeManifestFor(originalSaveFile, originalRomFile string, plan planResult, paddingBytes int64) error {
+	sourceSHA256, err := computeFileSHA256(plan.ResolvedSave, "source save file")
+	if err != nil {
+		return err
+	}
+	outputSHA256, err := computeFileSHA256(plan.OutputFile, "output file")
+	if err != nil {
+		return err
+	}
+
+	targetSize := int64(0)
+	if fi, err := os.Stat(plan.OutputFile); err == nil {
+		targetSize = fi.Size()
+	}
+
+	return writeManifest(Manifest{
+		ToolVersion:  Version,
+		SourceFile:   originalSaveFile,
+		SourceSHA256: sourceSHA256,
+		RomFile:      originalRomFile,
+		RomFormat:    plan.RomFormat,
+		RomSHA256:    plan.RomSHA256,
+		RomCRC32:     plan.RomCRC32,
+		RomMD5:       plan.RomMD5,
+		CleanTitle:   plan.CleanTitle,
+		OutputFile:   plan.OutputFile,
+		OutputSHA256: outputSHA256,
+		TargetSize:   targetSize,
+		PaddingBytes: paddingBytes,
+	})
+}
+
+// writeManifest writes m's JSON manifest and a plain <output>.sha256
+// sidecar, in the same "hash  filename" format as the sha256sum tool.
+func writeManifest(m Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Error encoding conversion manifest: %w", err)
+	}
+	if err := os.WriteFile(manifestPath(m.OutputFile), data, 0o644); err != nil {
+		return fmt.Errorf("Error writing conversion manifest '%s': %w", manifestPath(m.OutputFile), err)
+	}
+
+	sidecar := fmt.Sprintf("%s  %s\n", m.OutputSHA256, filepath.Base(m.OutputFile))
+	if err := os.WriteFile(sidecarPath(m.OutputFile), []byte(sidecar), 0o644); err != nil {
+		return fmt.Errorf("Error writing checksum sidecar '%s': %w", sidecarPath(m.OutputFile), err)
+	}
+	return nil
+}
+
+// Verify re-reads outputFile's manifest and confirms outputFile still
+// matches the SHA256 recorded both in the manifest and in its .sha256
+// sidecar, and that its size still matches the manifest's TargetSize.
+func Verify(outputFile string) error {
+	data, err := os.ReadFile(manifestPath(outputFile))
+	if err != nil {
+		return fmt.Errorf("Error reading conversion manifest '%s': %w", manifestPath(outputFile), err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("Error parsing conversion manifest '%s': %w", manifestPath(outputFile), err)
+	}
+
+	actualSHA256, err := computeFileSHA256(outputFile, "output file")
+	if err != nil {
+		return err
+	}
+	if actualSHA256 != m.OutputSHA256 {
+		return fmt.Errorf("Error: '%s' does not match its manifest (SHA256 %s, expected %s)", outputFile, actualSHA256, m.OutputSHA256)
+	}
+
+	sidecar, err := os.ReadFile(sidecarPath(outputFile))
+	if err != nil {
+		return fmt.Errorf("Error reading checksum sidecar '%s': %w", sidecarPath(outputFile), err)
+	}
+	sidecarFields := strings.Fields(string(sidecar))
+	if len(sidecarFields) == 0 || !strings.EqualFold(sidecarFields[0], m.OutputSHA256) {
+		return fmt.Errorf("Error: checksum sidecar '%s' does not match the manifest", sidecarPath(outputFile))
+	}
+
+	if m.TargetSize > 0 {
+		fi, err := os.Stat(outputFile)
+		if err != nil {
+			return fmt.Errorf("Error accessing '%s': %w", outputFile, err)
+		}
+		if fi.Size() != m.TargetSize {
+			return fmt.Errorf("Error: '%s' is %d bytes, manifest expects %d", outputFile, fi.Size(), m.TargetSize)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestVerifyRoundTrip confirms Verify passes on an untouched conversion
+// output, then catches the exact failure mode the manifest exists for:
+// silent corruption of the output file after the fact (e.g. from a
+// truncation/padding bug), and separately a sidecar that disagrees with the
+// manifest.
+func TestVerifyRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	romFile := writeTestRom(t, dir, "rom.z64")
+	saveFile := filepath.Join(dir, "save.eep")
+	if err := os.WriteFile(saveFile, []byte{0x01, 0x02, 0x03, 0x04}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	outDir := filepath.Join(dir, "out")
+	if err := os.Mkdir(outDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	outFile, err := Convert(saveFile, romFile, outDir)
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	if err := Verify(outFile); err != nil {
+		t.Fatalf("Verify on an untouched output: %v", err)
+	}
+
+	t.Run("corrupted output", func(t *testing.T) {
+		original, err := os.ReadFile(outFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		corrupted := append([]byte(nil), original...)
+		corrupted[0] ^= 0xFF
+		if err := os.WriteFile(outFile, corrupted, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		defer os.WriteFile(outFile, original, 0o644)
+
+		if err := Verify(outFile); err == nil {
+			t.Error("Verify did not catch a corrupted output file")
+		}
+	})
+
+	t.Run("truncated output", func(t *testing.T) {
+		original, err := os.ReadFile(outFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(outFile, original[:len(original)-1], 0o644); err != nil {
+			t.Fatal(err)
+		}
+		defer os.WriteFile(outFile, original, 0o644)
+
+		if err := Verify(outFile); err == nil {
+			t.Error("Verify did not catch a truncated output file")
+		}
+	})
+
+	t.Run("sidecar disagrees with manifest", func(t *testing.T) {
+		original, err := os.ReadFile(sidecarPath(outFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(sidecarPath(outFile), []byte("0000000000000000000000000000000000000000000000000000000000000000  "+filepath.Base(outFile)+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		defer os.WriteFile(sidecarPath(outFile), original, 0o644)
+
+		if err := Verify(outFile); err == nil {
+			t.Error("Verify did not catch a checksum sidecar that disagrees with the manifest")
+		}
+	})
+}
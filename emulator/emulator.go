@@ -0,0 +1,110 @@
+// Package emulator describes the save-file conventions of the N64
+// emulators save-converter moves files between: which extension each core
+// uses for a given save kind, the byte order it stores multi-byte saves in,
+// and what size it expects a Controller Pak image to be.
+package emulator
+
+import "encoding/binary"
+
+// SaveKind identifies the kind of N64 save data being converted. The same
+// kind can be stored under different extensions, sizes and byte orders by
+// different emulator cores.
+type SaveKind int
+
+const (
+	EEPROM SaveKind = iota
+	SRAM
+	FlashRAM
+	ControllerPak
+)
+
+func (k SaveKind) String() string {
+	switch k {
+	case EEPROM:
+		return "EEPROM"
+	case SRAM:
+		return "SRAM"
+	case FlashRAM:
+		return "FlashRAM"
+	case ControllerPak:
+		return "ControllerPak"
+	default:
+		return "Unknown"
+	}
+}
+
+// Profile describes one emulator's on-disk save conventions.
+type Profile interface {
+	// Name is the profile's identifier as used on the command line (--from/--to).
+	Name() string
+	// SaveExtension is the file extension this profile stores kind under, including the leading dot.
+	SaveExtension(kind SaveKind) string
+	// EndiannessFor is the word order this profile stores kind's multi-byte data in.
+	EndiannessFor(kind SaveKind) binary.ByteOrder
+	// TargetSize is the file size this profile expects for kind, or 0 if the profile doesn't pad/trim it.
+	TargetSize(kind SaveKind) int64
+	// OutputFilename builds the output filename for a converted save.
+	OutputFilename(title, hash, ext string) string
+}
+
+// extensionKinds maps every save extension save-converter recognizes to
+// its SaveKind, independent of which profile uses it.
+var extensionKinds = map[string]SaveKind{
+	".eep":    EEPROM,
+	".eeprom": EEPROM,
+	".sra":    SRAM,
+	".ram":    SRAM,
+	".fla":    FlashRAM,
+	".flash":  FlashRAM,
+	".pak":    ControllerPak,
+	".mpk":    ControllerPak,
+}
+
+// KindForExtension reports the SaveKind a save extension represents.
+func KindForExtension(ext string) (SaveKind, bool) {
+	kind, ok := extensionKinds[ext]
+	return kind, ok
+}
+
+// hardwareSizeRange is [min, max] in bytes, the legitimate sizes real N64
+// hardware produces for a SaveKind, independent of which emulator wrote it:
+// EEPROM comes in two capacities, SRAM and FlashRAM are fixed-size, and a
+// Controller Pak is either a single 32KB pak or a four-pak 128KB fullmempak.
+var hardwareSizeRange = map[SaveKind][2]int64{
+	EEPROM:        {512, 2 * 1024},
+	SRAM:          {32 * 1024, 32 * 1024},
+	FlashRAM:      {128 * 1024, 128 * 1024},
+	ControllerPak: {MempakSize, FullMempakSize},
+}
+
+// InSizeRange reports whether size is a legitimate on-disk size for kind, for
+// flagging a save file that matched a ROM by title but is too small or too
+// large to actually hold that kind of save (e.g. empty or truncated).
+func InSizeRange(kind SaveKind, size int64) bool {
+	r, ok := hardwareSizeRange[kind]
+	if !ok {
+		return true
+	}
+	return size >= r[0] && size <= r[1]
+}
+
+var registry = map[string]Profile{}
+
+func register(p Profile) {
+	registry[p.Name()] = p
+}
+
+// Get looks up a registered profile by name (e.g. "gopher64", "ares").
+func Get(name string) (Profile, bool) {
+	p, ok := registry[name]
+	return p, ok
+}
+
+// Names lists every registered profile name.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,112 @@
+package emulator
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// FullMempakSize and MempakSize are the two Controller Pak sizes
+// save-converter deals with: a single 32KB pak (one note-bearing chip, as
+// used by ares) and a 128KB fullmempak holding four of them back to back
+// (as used by gopher64/mupen64plus/simple64/project64).
+const (
+	FullMempakSize = 128 * 1024
+	MempakSize     = 32 * 1024
+)
+
+// simpleProfile is a table-driven Profile: every field is plain data, so
+// adding a new emulator is a new simpleProfile value rather than a new type.
+type simpleProfile struct {
+	name       string
+	extensions map[SaveKind]string
+	endianness map[SaveKind]binary.ByteOrder
+	sizes      map[SaveKind]int64
+}
+
+func (p simpleProfile) Name() string { return p.name }
+
+func (p simpleProfile) SaveExtension(kind SaveKind) string { return p.extensions[kind] }
+
+func (p simpleProfile) EndiannessFor(kind SaveKind) binary.ByteOrder { return p.endianness[kind] }
+
+func (p simpleProfile) TargetSize(kind SaveKind) int64 { return p.sizes[kind] }
+
+func (p simpleProfile) OutputFilename(title, hash, ext string) string {
+	return fmt.Sprintf("%s-%s%s", title, hash, ext)
+}
+
+func init() {
+	register(simpleProfile{
+		name: "gopher64",
+		extensions: map[SaveKind]string{
+			EEPROM: ".eep", SRAM: ".sra", FlashRAM: ".fla", ControllerPak: ".mpk",
+		},
+		endianness: map[SaveKind]binary.ByteOrder{
+			EEPROM: binary.BigEndian, SRAM: binary.LittleEndian, FlashRAM: binary.LittleEndian, ControllerPak: binary.BigEndian,
+		},
+		sizes: map[SaveKind]int64{ControllerPak: FullMempakSize},
+	})
+
+	register(simpleProfile{
+		name: "ares",
+		extensions: map[SaveKind]string{
+			EEPROM: ".eep", SRAM: ".sra", FlashRAM: ".fla", ControllerPak: ".pak",
+		},
+		endianness: map[SaveKind]binary.ByteOrder{
+			EEPROM: binary.BigEndian, SRAM: binary.BigEndian, FlashRAM: binary.BigEndian, ControllerPak: binary.BigEndian,
+		},
+		sizes: map[SaveKind]int64{ControllerPak: MempakSize},
+	})
+
+	register(simpleProfile{
+		name: "mupen64plus",
+		extensions: map[SaveKind]string{
+			EEPROM: ".eep", SRAM: ".sra", FlashRAM: ".fla", ControllerPak: ".mpk",
+		},
+		endianness: map[SaveKind]binary.ByteOrder{
+			EEPROM: binary.BigEndian, SRAM: binary.LittleEndian, FlashRAM: binary.LittleEndian, ControllerPak: binary.BigEndian,
+		},
+		sizes: map[SaveKind]int64{ControllerPak: FullMempakSize},
+	})
+
+	register(simpleProfile{
+		name: "simple64",
+		extensions: map[SaveKind]string{
+			EEPROM: ".eep", SRAM: ".sra", FlashRAM: ".fla", ControllerPak: ".mpk",
+		},
+		endianness: map[SaveKind]binary.ByteOrder{
+			EEPROM: binary.BigEndian, SRAM: binary.LittleEndian, FlashRAM: binary.LittleEndian, ControllerPak: binary.BigEndian,
+		},
+		sizes: map[SaveKind]int64{ControllerPak: FullMempakSize},
+	})
+
+	register(simpleProfile{
+		name: "project64",
+		extensions: map[SaveKind]string{
+			EEPROM: ".eeprom", SRAM: ".ram", FlashRAM: ".flash", ControllerPak: ".mpk",
+		},
+		endianness: map[SaveKind]binary.ByteOrder{
+			EEPROM: binary.BigEndian, SRAM: binary.BigEndian, FlashRAM: binary.BigEndian, ControllerPak: binary.BigEndian,
+		},
+		sizes: map[SaveKind]int64{ControllerPak: FullMempakSize},
+	})
+}
+
+// DefaultSource is the Profile save-converter assumed its input came from
+// before --from existed: ares naming for the short .fla/.sra/.eep/.pak
+// extensions; the long .flash/.ram/.eeprom/.mpk ones were assumed to already
+// be gopher64-compatible and were only renamed/padded, never byte-swapped,
+// so they default to gopher64 itself (matching its target endianness is
+// what keeps ConvertWithOptions from swapping them). It preserves the
+// original single-direction (> gopher64) behavior for callers that don't
+// pick a --from profile.
+func DefaultSource(ext string) Profile {
+	switch ext {
+	case ".fla", ".sra", ".eep", ".pak":
+		p, _ := Get("ares")
+		return p
+	default:
+		p, _ := Get("gopher64")
+		return p
+	}
+}
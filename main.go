@@ -1,81 +1,58 @@
 package main
 
 import (
-	"bytes"
-	"crypto/sha256"
-	"encoding/binary"
+	"bufio"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/sqweek/dialog"
+	"github.com/lualona66/save-converter/converter"
+	"github.com/lualona66/save-converter/emulator"
+	"github.com/lualona66/save-converter/mpk"
+	"github.com/lualona66/save-converter/romdb"
 )
 
-const (
-	maxSaveFileSize = 256 * 1024      // 256KB
-	maxRomFileSize  = 64 * 1024 * 1024 // 64MB
-	fullmempakSize  = 128 * 1024      // .mpk 32KB x 4 Controller Paks
-	mempakSize 	= 32 * 1024
-)
-
-var allowedSaveExtensions = map[string]bool{
-	".eep":    true,
-	".eeprom": true,
-	".fla":    true,
-	".flash":  true,
-	".mpk":    true,
-	".pak":    true,
-	".ram":    true,
-	".sra":    true,
-}
-
-var allowedRomExtensions = map[string]bool{
-	".z64": true,
-	".n64": true,
-	".v64": true,
-}
-
-var romFormats = map[string]string{
-	"z64": "\x80\x37\x12\x40", // Big-endian
-	"n64": "\x40\x12\x37\x80", // Little-endian
-	"v64": "\x37\x80\x40\x12", // Byte-swapped
-}
-
-var aresSaveFormatMap = map[string]string{
-	".eeprom": ".eep",
-	".flash":  ".fla",
-	".pak":    ".mpk",
-	".ram":    ".sra",
-}
-
-var nonConversionExtensions = map[string]bool{
-	".eep":    true,
-	".eeprom": true,
-	".flash":  true,
-	".mpk":    true,
-	".pak":    true,
-	".ram":    true,
-}
-
-var ConversionExtensions = map[string]bool{
-	".fla":    true,
-	".sra":    true,
-}
+// datPathEnvVar is the fallback for --dat when no flag is passed on the command line.
+const datPathEnvVar = "SAVE_CONVERTER_DAT"
 
 func printUsage() {
 	fmt.Println("N64 emulator save converter: > Gopher64")
-	fmt.Println("\nValid save files:", strings.Join(getKeys(allowedSaveExtensions), " "))
-	fmt.Println("Valid N64 rom files:", strings.Join(getKeys(allowedRomExtensions), " "))
+	fmt.Println("\nValid save files:", strings.Join(getKeys(converter.AllowedSaveExtensions), " "))
+	fmt.Println("Valid N64 rom files:", strings.Join(getKeys(converter.AllowedRomExtensions), " "))
 	fmt.Println("\nUsage:")
-	switch runtime.GOOS {
-		case "windows":
-			fmt.Println("    save-converter.exe <save_file> <N64_rom_file>")
-		default:
-			fmt.Println("    ./save-converter <save_file> <N64_rom_file>") // General case for other OSes
+	exe := "./save-converter"
+	if runtime.GOOS == "windows" {
+		exe = "save-converter.exe"
+	}
+	fmt.Printf("    %s [--from profile] [--to profile] [--dat nintendo64.dat] [--exclude-bootcode] <save_file> <N64_rom_file>\n", exe)
+	fmt.Printf("    %s --batch <saves_dir> <roms_dir> --out <out_dir> [--from profile] [--to profile] [--map mapping.txt] [--dry-run] [--dat nintendo64.dat] [--exclude-bootcode]\n", exe)
+	fmt.Printf("\n    --from/--to: emulator profile to convert between (%s), default --from is inferred from the save's extension and --to is gopher64\n", strings.Join(emulator.Names(), ", "))
+	fmt.Printf("    --dat: Logiqx/No-Intro DAT file to identify ROMs and name outputs after their canonical title (env: %s)\n", datPathEnvVar)
+	fmt.Println("    --exclude-bootcode: compute the DAT-lookup CRC32/MD5 without the 0x1000-byte header/bootstrap area")
+	fmt.Println("    --batch: convert every save in <saves_dir> using its matching ROM in <roms_dir>")
+	fmt.Println("    --map: optional text file pairing save files to ROM files (one \"save<TAB>rom\" pair per line) instead of title matching")
+	fmt.Println("    --dry-run: report planned output filenames and mismatches without writing anything")
+	fmt.Println("\n    " + exe + " mpk ls <pak_file>")
+	fmt.Println("    " + exe + " mpk extract <pak_file> <note_index> <out_file.note>")
+	fmt.Println("    " + exe + " mpk merge <out_file.mpk> <src_pak>...")
+	fmt.Println("    mpk: inspect, extract, or merge Controller Pak notes (pak_file may be a 32KB pak or a 128KB fullmempak)")
+	fmt.Println("\n    " + exe + " verify <output_file>")
+	fmt.Println("    verify: confirm <output_file> still matches the conversion manifest written alongside it")
+}
+
+// resolveProfile looks up an emulator profile by name, returning a
+// descriptive error listing valid names on failure.
+func resolveProfile(name string) (emulator.Profile, error) {
+	p, ok := emulator.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("Error: unknown emulator profile '%s'. Valid profiles are: %s", name, strings.Join(emulator.Names(), ", "))
 	}
+	return p, nil
 }
 
 // getKeys helper function to extract keys from a map for usage printing
@@ -87,350 +64,502 @@ func getKeys(m map[string]bool) []string {
 	return keys
 }
 
-// processRom extracts Rom name and computes hash
-func processRom(romFile string) (cleanTitle string, hashHex string, err error) {
-	romHandle, err := os.Open(romFile)
-	if err != nil {
-		return "", "", fmt.Errorf("Error opening ROM file: %w", err)
-	}
-	defer romHandle.Close()
+// check for arguments, open file picker if none
+func main() {
+	if len(os.Args) == 1 {
+		fmt.Println("No command-line arguments provided. Opening file selector...")
+		fmt.Println("\n1: Pick the save file you want to convert.")
+		fmt.Println("2: Pick the N64 rom file associated with your save file.")
+
+		var extensions []string
+		var romExts []string
 
-	rawHeader := make([]byte, 0x40)
-	if _, err := io.ReadFull(romHandle, rawHeader); err != nil {
-		return "", "", fmt.Errorf("Error reading ROM header: %w", err)
+		for ext := range converter.AllowedSaveExtensions {
+			extensions = append(extensions, strings.TrimPrefix(ext, "."))
+		}
+		for ext := range converter.AllowedRomExtensions {
+			romExts = append(romExts, strings.TrimPrefix(ext, "."))
+		}
+		for ext := range converter.AllowedArchiveExtensions {
+			archiveExt := strings.TrimPrefix(ext, ".")
+			extensions = append(extensions, archiveExt)
+			romExts = append(romExts, archiveExt)
+		}
+
+		saveFile, err := dialog.File().Title("Select Save File").Filter("Save Files", extensions...).Load()
+		if err != nil {
+			fmt.Println("\nError selecting save file or operation cancelled.")
+			return
+		}
+
+		romFile, err := dialog.File().Title("Select N64 ROM File").Filter("ROM Files", romExts...).Load()
+		if err != nil {
+			fmt.Println("\nError selecting ROM file or operation cancelled.", err)
+			return
+		}
+
+		os.Args = []string{"save-converter", saveFile, romFile}
 	}
 
-	romMode, err := detectRomFormat(rawHeader[:4])
-	if err != nil {
-		return "", "", err
+	for _, arg := range os.Args[1:] {
+		if arg == "-h" || arg == "--help" {
+			printUsage()
+			return
+		}
 	}
 
-	convHeader := convertHeaderEndianness(rawHeader, romMode)
-	cleanTitle = extractCleanTitle(convHeader)
-	hashHex, err = computeSHA256(romFile)
-	if err != nil {
-		return "", "", err
+	if os.Args[1] == "--batch" {
+		if err := runBatch(os.Args[2:]); err != nil {
+			fmt.Println(err)
+		}
+		return
 	}
 
-	return cleanTitle, hashHex, nil
-}
+	if os.Args[1] == "mpk" {
+		if err := runMpk(os.Args[2:]); err != nil {
+			fmt.Println(err)
+		}
+		return
+	}
 
-// checks rom header and assigns correct rom format
-func detectRomFormat(magicBytes []byte) (string, error) {
-	for mode, magic := range romFormats {
-		if string(magicBytes) == magic {
-			return mode, nil
+	if os.Args[1] == "verify" {
+		if len(os.Args) != 3 {
+			fmt.Println("Error: verify expects <output_file>")
+			return
 		}
+		if err := converter.Verify(os.Args[2]); err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("%s matches its conversion manifest\n", os.Args[2])
+		return
 	}
-	return "", fmt.Errorf("Error unsupported ROM format based on magic bytes: %x", magicBytes)
-}
 
-// converts and extracts header for rom name
-func convertHeaderEndianness(rawHeader []byte, romMode string) []byte {
-	convHeader := make([]byte, len(rawHeader))
-	switch romMode {
-		case "z64":
-			copy(convHeader, rawHeader)
-		case "n64":
-			for i := 0; i < len(rawHeader); i += 4 {
-				binary.BigEndian.PutUint32(convHeader[i:i+4], binary.LittleEndian.Uint32(rawHeader[i:i+4]))
+	datPath := os.Getenv(datPathEnvVar)
+	excludeBootcode := false
+	fromName := ""
+	toName := ""
+	var positionalArgs []string
+	rawArgs := os.Args[1:]
+	for i := 0; i < len(rawArgs); i++ {
+		switch rawArgs[i] {
+		case "--dat":
+			if i+1 >= len(rawArgs) {
+				fmt.Println("Error: --dat requires a path argument")
+				return
+			}
+			datPath = rawArgs[i+1]
+			i++
+		case "--exclude-bootcode":
+			excludeBootcode = true
+		case "--from":
+			if i+1 >= len(rawArgs) {
+				fmt.Println("Error: --from requires a profile name")
+				return
 			}
-		case "v64":
-			for i := 0; i < len(rawHeader); i += 2 {
-				binary.BigEndian.PutUint16(convHeader[i:i+2], binary.LittleEndian.Uint16(rawHeader[i:i+2]))
+			fromName = rawArgs[i+1]
+			i++
+		case "--to":
+			if i+1 >= len(rawArgs) {
+				fmt.Println("Error: --to requires a profile name")
+				return
 			}
+			toName = rawArgs[i+1]
+			i++
+		default:
+			positionalArgs = append(positionalArgs, rawArgs[i])
+		}
 	}
-	return convHeader
-}
 
-// extracts rom name from converted header then removes special characters and trailing spaces
-func extractCleanTitle(convHeader []byte) string {
-	titleField := convHeader[0x20 : 0x20+20]
-	titleBytes := bytes.TrimRight(titleField, " ")
-	title := string(titleBytes)
+	if len(positionalArgs) != 2 {
+		fmt.Println("Invalid Argument. Expected save file and ROM")
+		fmt.Println("  --help for command usage")
+		return
+	}
 
-	var cleanTitleRunes strings.Builder
-	for _, r := range title {
-		if (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == ' ' {
-			cleanTitleRunes.WriteRune(r)
+	dat, err := loadDat(datPath)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	opts := converter.Options{DAT: dat, ExcludeBootcode: excludeBootcode}
+	if fromName != "" {
+		if opts.From, err = resolveProfile(fromName); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	if toName != "" {
+		if opts.To, err = resolveProfile(toName); err != nil {
+			fmt.Println(err)
+			return
 		}
 	}
-	return cleanTitleRunes.String()
-}
 
-// compute SHA256 hash for rom file and return HEX string
-func computeSHA256(romFile string) (string, error) {
-	romHashFile, err := os.Open(romFile)
+	outputFile, err := converter.ConvertWithOptions(positionalArgs[0], positionalArgs[1], ".", opts)
 	if err != nil {
-		return "", fmt.Errorf("Error opening ROM file for hashing: %w", err)
+		fmt.Println(err)
+		return
 	}
-	defer romHashFile.Close()
 
-	hasher := sha256.New()
-	if _, err := io.CopyBuffer(hasher, romHashFile, make([]byte, 4096)); err != nil {
-		return "", fmt.Errorf("Error computing SHA256 for ROM file: %w", err)
-	}
-	hashSum := hasher.Sum(nil)
-	return fmt.Sprintf("%X", hashSum), nil
+	fmt.Printf("\nFile converted successfully: %s\n", outputFile)
 }
 
-// read the save file, processes it in 4-byte chunks (swapping endianness)
-func convertSaveFile(src, dst string) error {
-	fmt.Printf("\nConverting Data from: %s\n", src)
-	inFile, err := os.Open(src)
-	if err != nil {
-		return fmt.Errorf("Error opening save file: %w", err)
+// loadDat loads the DAT file at path, if one was given, returning a nil DB otherwise.
+func loadDat(path string) (*romdb.DB, error) {
+	if path == "" {
+		return nil, nil
 	}
-	defer inFile.Close()
+	return romdb.Load(path)
+}
 
-	outFile, err := os.Create(dst)
-	if err != nil {
-		return fmt.Errorf("Error creating output file: %w", err)
+// runMpk implements `save-converter mpk ls|extract|merge`.
+func runMpk(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("Error: mpk requires a subcommand: ls, extract, merge")
 	}
-	defer outFile.Close()
 
-	buf := make([]byte, 4)
-	for {
-		n, err := io.ReadFull(inFile, buf)
+	switch args[0] {
+	case "ls":
+		if len(args) != 2 {
+			return fmt.Errorf("Error: mpk ls expects <pak_file>")
+		}
+		notes, err := mpk.List(args[1])
 		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			return fmt.Errorf("Error reading save file: %w", err)
+			return err
 		}
-		if n != 4 {
-			return fmt.Errorf("Error unexpected read size from save file: got %d bytes, expected 4", n)
+		for i, n := range notes {
+			fmt.Printf("[%d] pak %d slot %d: %-16q game=%s pub=%s start=%d pages=%d\n", i, n.Pak, n.Index, n.Name, n.GameCode, n.PublisherCode, n.StartPage, n.PageCount)
 		}
+		return nil
 
-		binary.LittleEndian.PutUint32(buf, binary.BigEndian.Uint32(buf))
+	case "extract":
+		if len(args) != 4 {
+			return fmt.Errorf("Error: mpk extract expects <pak_file> <note_index> <out_file>")
+		}
+		noteIndex, err := strconv.Atoi(args[2])
+		if err != nil {
+			return fmt.Errorf("Error: invalid note index '%s': %w", args[2], err)
+		}
+		if err := mpk.Extract(args[1], noteIndex, args[3]); err != nil {
+			return err
+		}
+		fmt.Printf("Extracted note %d from %s to %s\n", noteIndex, args[1], args[3])
+		return nil
 
-		if _, err := outFile.Write(buf); err != nil {
-			return fmt.Errorf("Error writing to output file: %w", err)
+	case "merge":
+		if len(args) < 3 {
+			return fmt.Errorf("Error: mpk merge expects <out_file> <src_pak>...")
+		}
+		if err := mpk.Merge(args[1], args[2:]...); err != nil {
+			return err
 		}
+		fmt.Printf("Merged %d Controller Pak(s) into %s\n", len(args[2:]), args[1])
+		return nil
+
+	default:
+		return fmt.Errorf("Error: unknown mpk subcommand '%s'. Valid subcommands are: ls, extract, merge", args[0])
 	}
+}
 
-	return outFile.Sync()
+// pairing describes one save/ROM pair discovered for batch conversion.
+type pairing struct {
+	SaveFile string
+	RomFile  string
 }
 
-// checks file size and trims or pads as needed then save file. (4x data copy if 32KB save)
-func copyFile(src, dst string, targetSize int64) error {
-	fmt.Printf("\nCopying Data from: %s\n", src)
-	in, err := os.Open(src)
-	if err != nil {
-		return fmt.Errorf("Error opening source file: %w", err)
+// runBatch implements `save-converter --batch <saves_dir> <roms_dir> --out <out_dir>`.
+func runBatch(args []string) error {
+	outDir := ""
+	mapFile := ""
+	dryRun := false
+	datPath := os.Getenv(datPathEnvVar)
+	excludeBootcode := false
+	fromName := ""
+	toName := ""
+	var positionalArgs []string
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--out":
+			if i+1 >= len(args) {
+				return fmt.Errorf("Error: --out requires a directory argument")
+			}
+			outDir = args[i+1]
+			i++
+		case "--map":
+			if i+1 >= len(args) {
+				return fmt.Errorf("Error: --map requires a file argument")
+			}
+			mapFile = args[i+1]
+			i++
+		case "--dry-run":
+			dryRun = true
+		case "--dat":
+			if i+1 >= len(args) {
+				return fmt.Errorf("Error: --dat requires a path argument")
+			}
+			datPath = args[i+1]
+			i++
+		case "--exclude-bootcode":
+			excludeBootcode = true
+		case "--from":
+			if i+1 >= len(args) {
+				return fmt.Errorf("Error: --from requires a profile name")
+			}
+			fromName = args[i+1]
+			i++
+		case "--to":
+			if i+1 >= len(args) {
+				return fmt.Errorf("Error: --to requires a profile name")
+			}
+			toName = args[i+1]
+			i++
+		default:
+			positionalArgs = append(positionalArgs, args[i])
+		}
 	}
-	defer in.Close()
 
-	out, err := os.Create(dst)
-	if err != nil {
-		return fmt.Errorf("Error creating destination file: %w", err)
+	if len(positionalArgs) != 2 {
+		return fmt.Errorf("Error: --batch expects <saves_dir> <roms_dir> --out <out_dir>")
 	}
-	defer out.Close()
-
-	if targetSize == 0 {
-		_, err = io.Copy(out, in)
-		if err != nil {
-			return fmt.Errorf("Error copying file: %w", err)
-		}
-		return out.Sync()
+	if outDir == "" {
+		return fmt.Errorf("Error: --batch requires --out <out_dir>")
 	}
+	savesDir, romsDir := positionalArgs[0], positionalArgs[1]
 
-	fileData, err := io.ReadAll(in)
+	dat, err := loadDat(datPath)
 	if err != nil {
-		return fmt.Errorf("Error reading source file into memory: %w", err)
-	}
-	fileSize := int64(len(fileData))
-	if fileSize == 0 {
-		return fmt.Errorf("Error source file is empty")
+		return err
 	}
-
-	// fmt.Println("fileSize before initial processing:", fileSize)
-
-
-	if fileSize > fullmempakSize {
-		fileData = fileData[:fullmempakSize]
-		fileSize = fullmempakSize
-		fmt.Println("Source file was larger than fullmempakSize, trimmed to 128KB.")
+	opts := converter.Options{DAT: dat, ExcludeBootcode: excludeBootcode}
+	if fromName != "" {
+		if opts.From, err = resolveProfile(fromName); err != nil {
+			return err
+		}
 	}
-
-
-	if fileSize <= mempakSize {
-		paddingSize := mempakSize - fileSize
-		fmt.Println("File smaller than mempakSize. Padding", paddingSize, "bytes to mempakSize")
-		padding := make([]byte, paddingSize) // Zero-filled padding
-		paddedFileData := make([]byte, 0, mempakSize)
-		paddedFileData = append(paddedFileData, fileData...)
-		paddedFileData = append(paddedFileData, padding...)
-		fileData = paddedFileData
-		fileSize = int64(len(fileData))
+	if toName != "" {
+		if opts.To, err = resolveProfile(toName); err != nil {
+			return err
+		}
 	}
-	// fmt.Println("fileSize after padding:", fileSize)
-
 
-	_, err = out.Write(fileData)
+	pairs, mismatches, err := pairSavesAndRoms(savesDir, romsDir, mapFile)
 	if err != nil {
-		return fmt.Errorf("Error writing initial data (trimmed/padded) to destination file: %w", err)
+		return err
 	}
 
-
-	numCopies := 1 // Start with 1 because we already have base content
-	if targetSize > fileSize {
-		numCopies += int((targetSize - fileSize) / fileSize)
+	for _, m := range mismatches {
+		fmt.Println("Mismatch:", m)
 	}
 
-	bytesToPad := 0
-	if targetSize > fileSize {
-		bytesToPad = int((targetSize - fileSize) % fileSize)
+	if !dryRun {
+		if err := os.MkdirAll(outDir, 0o755); err != nil {
+			return fmt.Errorf("Error creating output directory '%s': %w", outDir, err)
+		}
 	}
 
-
-	// fmt.Println("numCopies", numCopies)
-	// fmt.Println("bytesToPad", bytesToPad)
-
-
-	if numCopies > 1 {
-		for i := 1; i < numCopies; i++ {
-		_, err = out.Write(fileData)
-		if err != nil {
-			return fmt.Errorf("Error writing repeated data to destination file: %w", err)
+	if dryRun {
+		for _, p := range pairs {
+			outputFile, err := converter.Plan(p.SaveFile, p.RomFile, outDir, opts)
+			if err != nil {
+				fmt.Printf("Mismatch: %s + %s: %v\n", p.SaveFile, p.RomFile, err)
+				continue
 			}
+			fmt.Printf("Would convert %s + %s -> %s\n", p.SaveFile, p.RomFile, outputFile)
 		}
+		return nil
 	}
 
+	return convertPairsConcurrently(pairs, outDir, opts)
+}
 
-	if bytesToPad > 0 {
-		fmt.Println("Padding", bytesToPad, "final bytes")
-		extrapadding := make([]byte, bytesToPad)
-		_, err = out.Write(extrapadding)
-		if err != nil {
-			return fmt.Errorf("Error writing final padding data to destination file: %w", err)
-		}
+// convertPairsConcurrently runs pairs through converter.ConvertWithOptions
+// across a worker pool sized to runtime.NumCPU().
+func convertPairsConcurrently(pairs []pairing, outDir string, opts converter.Options) error {
+	jobs := make(chan pairing)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var failures []string
+
+	workers := runtime.NumCPU()
+	if workers < 1 {
+		workers = 1
+	}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				outputFile, err := converter.ConvertWithOptions(p.SaveFile, p.RomFile, outDir, opts)
+				mu.Lock()
+				if err != nil {
+					failures = append(failures, fmt.Sprintf("%s + %s: %v", p.SaveFile, p.RomFile, err))
+				} else {
+					fmt.Printf("Converted %s + %s -> %s\n", p.SaveFile, p.RomFile, outputFile)
+				}
+				mu.Unlock()
+			}
+		}()
 	}
 
+	for _, p := range pairs {
+		jobs <- p
+	}
+	close(jobs)
+	wg.Wait()
 
-	return out.Sync()
+	if len(failures) > 0 {
+		return fmt.Errorf("Error: %d of %d conversions failed:\n%s", len(failures), len(pairs), strings.Join(failures, "\n"))
+	}
+	return nil
 }
 
+// pairSavesAndRoms pairs every save file in savesDir with a ROM file in
+// romsDir, either via mapFile (a "save<TAB>rom" pair per line, relative to
+// their respective directories) or, when mapFile is empty, by matching the
+// save's base filename against each ROM's header title. It returns the
+// resolved pairs plus a list of human-readable mismatches (missing ROM for
+// a save, a save title matching more than one ROM, or a matched save whose
+// size is out of range for its kind).
+func pairSavesAndRoms(savesDir, romsDir, mapFile string) ([]pairing, []string, error) {
+	if mapFile != "" {
+		return pairFromMapFile(mapFile, savesDir, romsDir)
+	}
 
-func validateFile(filePath string, isDir bool, maxSize int64, allowedExtensions map[string]bool, fileType string) error {
-	fmt.Println("Validating",fileType, filePath)
-	fileInfo, err := os.Stat(filePath)
+	saveFiles, err := listFilesWithExt(savesDir, unionExts(converter.AllowedSaveExtensions, converter.AllowedArchiveExtensions))
 	if err != nil {
-		return fmt.Errorf("Error accessing %s file '%s': %w", fileType, filePath, err)
-	}
-	if fileInfo.IsDir() != isDir {
-		if isDir {
-			return fmt.Errorf("Error: '%s' is not a directory, expected a directory for %s file", filePath, fileType)
-		}
-		return fmt.Errorf("Error: '%s' is a directory, expected a file for %s file", filePath, fileType)
+		return nil, nil, err
 	}
-	if !isDir && fileInfo.Size() > maxSize {
-		return fmt.Errorf("Error: %s file '%s' is too large (max size: %dKB)", fileType, filePath, maxSize/1024)
-	}
-	if !isDir && allowedExtensions != nil {
-		ext := filepath.Ext(filePath)
-		if !allowedExtensions[ext] {
-			allowedExts := strings.Join(getKeys(allowedExtensions), ", ")
-			return fmt.Errorf("Errorr: unsupported %s file extension '%s'. Allowed extensions are: %s", fileType, ext, allowedExts)
-		}
+	romFiles, err := listFilesWithExt(romsDir, unionExts(converter.AllowedRomExtensions, converter.AllowedArchiveExtensions))
+	if err != nil {
+		return nil, nil, err
 	}
-	return nil
-}
-
-// check for arguments, open file picker if none
-func main() {
-	if len(os.Args) == 1 {
-		fmt.Println("No command-line arguments provided. Opening file selector...")
-		fmt.Println("\n1: Pick the save file you want to convert.")
-		fmt.Println("2: Pick the N64 rom file associated with your save file.")
-
-
-		var extensions []string
-		var romFormats []string
-
-		for ext := range allowedSaveExtensions {
-			extensions = append(extensions, strings.TrimPrefix(ext, "."))
-		}
-		for ext := range allowedRomExtensions {
-			romFormats = append(romFormats, strings.TrimPrefix(ext, "."))
-		}
 
-
-		saveFile, err := dialog.File().Title("Select Save File").Filter("Save Files", extensions...).Load()
+	romTitles := make(map[string][]string) // normalized title -> rom paths
+	for _, romFile := range romFiles {
+		title, err := converter.ExtractTitle(romFile)
 		if err != nil {
-			fmt.Println("\nError selecting save file or operation cancelled.")
-			return
+			return nil, nil, err
 		}
-
-
-		romFile, err := dialog.File().Title("Select N64 ROM File").Filter("ROM Files", romFormats...).Load()
-		if err != nil {
-			fmt.Println("\nError selecting ROM file or operation cancelled.", err)
-			return
+		key := normalizeTitle(title)
+		romTitles[key] = append(romTitles[key], romFile)
+	}
+
+	var pairs []pairing
+	var mismatches []string
+	for _, saveFile := range saveFiles {
+		base := strings.TrimSuffix(filepath.Base(saveFile), filepath.Ext(saveFile))
+		matches := romTitles[normalizeTitle(base)]
+		switch len(matches) {
+		case 0:
+			mismatches = append(mismatches, fmt.Sprintf("no ROM found for save '%s'", saveFile))
+		case 1:
+			if mismatch := sizeRangeMismatch(saveFile); mismatch != "" {
+				mismatches = append(mismatches, mismatch)
+				continue
+			}
+			pairs = append(pairs, pairing{SaveFile: saveFile, RomFile: matches[0]})
+		default:
+			mismatches = append(mismatches, fmt.Sprintf("save '%s' matches %d ROMs by title, skipping: %s", saveFile, len(matches), strings.Join(matches, ", ")))
 		}
+	}
 
+	return pairs, mismatches, nil
+}
 
-		os.Args = []string{"save-converter", saveFile, romFile}
+// sizeRangeMismatch reports a dry-run mismatch message when saveFile's size
+// is outside the legitimate hardware range for its extension's SaveKind
+// (e.g. a save matched to a ROM by title but empty or truncated), or ""
+// when it's in range, or when its kind/size can't be determined (an
+// archive-wrapped save, whose real size isn't known without extracting it).
+func sizeRangeMismatch(saveFile string) string {
+	kind, ok := emulator.KindForExtension(filepath.Ext(saveFile))
+	if !ok {
+		return ""
+	}
+	fi, err := os.Stat(saveFile)
+	if err != nil {
+		return ""
 	}
-
-	for _, arg := range os.Args[1:] {
-		if arg == "-h" || arg == "--help" {
-			printUsage()
-			return
-		}
+	if !emulator.InSizeRange(kind, fi.Size()) {
+		return fmt.Sprintf("save '%s' is %d bytes, out of range for a %s save", saveFile, fi.Size(), kind)
 	}
+	return ""
+}
 
-	if len(os.Args) != 3 {
-		fmt.Println("Invalid Argument. Expected save file and ROM")
-		fmt.Println("  --help for command usage")
-		return
+// pairFromMapFile reads a "save<TAB>rom" pairing file, resolving each
+// entry relative to savesDir/romsDir.
+func pairFromMapFile(mapFile, savesDir, romsDir string) ([]pairing, []string, error) {
+	f, err := os.Open(mapFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error opening mapping file '%s': %w", mapFile, err)
 	}
+	defer f.Close()
 
-	saveFile := os.Args[1]
-	if err := validateFile(saveFile, false, maxSaveFileSize, allowedSaveExtensions, "Save:"); err != nil {
-		fmt.Println(err)
-		return
-	}
-	saveExt := filepath.Ext(saveFile)
+	var pairs []pairing
+	var mismatches []string
 
-	romFile := os.Args[2]
-	if err := validateFile(romFile, false, maxRomFileSize, allowedRomExtensions, "ROM:"); err != nil {
-		fmt.Println(err)
-		return
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if strings.Contains(line, "\t") {
+			// Tab-separated: save and ROM names may themselves contain spaces.
+			fields = strings.FieldsFunc(line, func(r rune) bool { return r == '\t' })
+		}
+		if len(fields) != 2 {
+			mismatches = append(mismatches, fmt.Sprintf("malformed mapping line: %q", line))
+			continue
+		}
+		pairs = append(pairs, pairing{
+			SaveFile: filepath.Join(savesDir, fields[0]),
+			RomFile:  filepath.Join(romsDir, fields[1]),
+		})
 	}
-
-	cleanTitle, hashHex, err := processRom(romFile)
-	if err != nil {
-		fmt.Println(err)
-		return
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("Error reading mapping file '%s': %w", mapFile, err)
 	}
 
-	outputExt := saveExt
-	if mappedExt, ok := aresSaveFormatMap[saveExt]; ok {
-		outputExt = mappedExt
-	}
-	outputFile := fmt.Sprintf("%s-%s%s", cleanTitle, hashHex, outputExt)
+	return pairs, mismatches, nil
+}
 
+// listFilesWithExt returns the files directly inside dir whose extension is in allowedExtensions.
+func listFilesWithExt(dir string, allowedExtensions map[string]bool) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading directory '%s': %w", dir, err)
+	}
 
-	if ConversionExtensions[saveExt] {
-		if err := convertSaveFile(saveFile, outputFile); err != nil {
-			fmt.Println(err)
-			return
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
 		}
-	} else {
-		targetSize := int64(0)
-		switch saveExt  {
-			case ".mpk":
-				targetSize = fullmempakSize
-			case ".pak":
-				targetSize = fullmempakSize
+		if allowedExtensions[filepath.Ext(entry.Name())] {
+			files = append(files, filepath.Join(dir, entry.Name()))
 		}
+	}
+	return files, nil
+}
 
-		if err := copyFile(saveFile, outputFile, targetSize); err != nil {
-			fmt.Println(err)
-			return
+// normalizeTitle loosens a title for fuzzy save/ROM matching.
+func normalizeTitle(title string) string {
+	return strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
+
+// unionExts merges two extension sets, e.g. a file-type's own extensions and the archive extensions that may contain it.
+func unionExts(sets ...map[string]bool) map[string]bool {
+	merged := make(map[string]bool)
+	for _, set := range sets {
+		for ext := range set {
+			merged[ext] = true
 		}
 	}
-
-	fmt.Printf("\nFile converted successfully: %s\n", outputFile)
+	return merged
 }
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lualona66/save-converter/converter"
+)
+
+// writeTestRomHeader writes a minimal valid z64 ROM (just enough header for
+// title extraction) carrying title at its header offset.
+func writeTestRomHeader(t *testing.T, path, title string) {
+	t.Helper()
+	header := make([]byte, 0x40)
+	copy(header, "\x80\x37\x12\x40")
+	copy(header[0x20:], title)
+	if err := os.WriteFile(path, header, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestPairSavesAndRomsByTitle covers the three mismatch categories
+// pairSavesAndRoms reports (missing ROM, ambiguous title match, size out of
+// range) alongside a normal title-matched pair.
+func TestPairSavesAndRomsByTitle(t *testing.T) {
+	dir := t.TempDir()
+	savesDir := filepath.Join(dir, "saves")
+	romsDir := filepath.Join(dir, "roms")
+	for _, d := range []string{savesDir, romsDir} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mustWriteSave := func(name string, size int) {
+		if err := os.WriteFile(filepath.Join(savesDir, name), make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mustWriteSave("Game One.eep", 512)
+	writeTestRomHeader(t, filepath.Join(romsDir, "Game One.z64"), "Game One")
+
+	mustWriteSave("Orphan Save.eep", 512)
+
+	mustWriteSave("Ambiguous.eep", 512)
+	writeTestRomHeader(t, filepath.Join(romsDir, "Ambiguous (A).z64"), "Ambiguous")
+	writeTestRomHeader(t, filepath.Join(romsDir, "Ambiguous (B).z64"), "Ambiguous")
+
+	mustWriteSave("Too Small.eep", 4)
+	writeTestRomHeader(t, filepath.Join(romsDir, "Too Small.z64"), "Too Small")
+
+	pairs, mismatches, err := pairSavesAndRoms(savesDir, romsDir, "")
+	if err != nil {
+		t.Fatalf("pairSavesAndRoms: %v", err)
+	}
+
+	if len(pairs) != 1 || filepath.Base(pairs[0].SaveFile) != "Game One.eep" {
+		t.Errorf("pairs = %+v, want exactly the Game One match", pairs)
+	}
+
+	for _, want := range []string{"no ROM found for save", "matches 2 ROMs by title", "out of range"} {
+		found := false
+		for _, m := range mismatches {
+			if strings.Contains(m, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("mismatches %v missing one containing %q", mismatches, want)
+		}
+	}
+}
+
+// TestPairFromMapFile covers a well-formed mapping, a malformed line, and
+// comment/blank-line handling.
+func TestPairFromMapFile(t *testing.T) {
+	dir := t.TempDir()
+	savesDir := filepath.Join(dir, "saves")
+	romsDir := filepath.Join(dir, "roms")
+
+	mapPath := filepath.Join(dir, "map.txt")
+	content := "# a comment\n\nsave1.eep rom1.z64\nmalformed-line\nsave2.eep rom2.z64\n"
+	if err := os.WriteFile(mapPath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	pairs, mismatches, err := pairFromMapFile(mapPath, savesDir, romsDir)
+	if err != nil {
+		t.Fatalf("pairFromMapFile: %v", err)
+	}
+
+	if len(pairs) != 2 {
+		t.Fatalf("pairs = %+v, want 2", pairs)
+	}
+	if pairs[0].SaveFile != filepath.Join(savesDir, "save1.eep") || pairs[0].RomFile != filepath.Join(romsDir, "rom1.z64") {
+		t.Errorf("pairs[0] = %+v", pairs[0])
+	}
+	if pairs[1].SaveFile != filepath.Join(savesDir, "save2.eep") || pairs[1].RomFile != filepath.Join(romsDir, "rom2.z64") {
+		t.Errorf("pairs[1] = %+v", pairs[1])
+	}
+
+	if len(mismatches) != 1 || !strings.Contains(mismatches[0], "malformed-line") {
+		t.Errorf("mismatches = %v, want exactly one mentioning the malformed line", mismatches)
+	}
+}
+
+// TestConvertPairsConcurrently runs a couple of pairs through the worker
+// pool and confirms every pair produces an output file.
+func TestConvertPairsConcurrently(t *testing.T) {
+	dir := t.TempDir()
+	outDir := filepath.Join(dir, "out")
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var pairs []pairing
+	for i, title := range []string{"Alpha", "Beta"} {
+		saveFile := filepath.Join(dir, fmt.Sprintf("save%d.eep", i))
+		if err := os.WriteFile(saveFile, []byte{byte(i), 0, 0, 0}, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		romFile := filepath.Join(dir, fmt.Sprintf("rom%d.z64", i))
+		writeTestRomHeader(t, romFile, title)
+		pairs = append(pairs, pairing{SaveFile: saveFile, RomFile: romFile})
+	}
+
+	if err := convertPairsConcurrently(pairs, outDir, converter.Options{}); err != nil {
+		t.Fatalf("convertPairsConcurrently: %v", err)
+	}
+
+	entries, err := os.ReadDir(outDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs := 0
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".eep") {
+			outputs++
+		}
+	}
+	if outputs != len(pairs) {
+		t.Errorf("found %d .eep outputs in outDir, want %d", outputs, len(pairs))
+	}
+}
@@ -0,0 +1,354 @@
+// Package mpk parses and rewrites the N64 Controller Pak's 32KB on-disk
+// layout: a header page, two redundant page-link tables ("TOC"), a 16-entry
+// note directory, and 123 usable data pages. A gopher64-style fullmempak
+// (see emulator.FullMempakSize) is just four of these 32KB images
+// concatenated back to back, so every function here also accepts one.
+package mpk
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lualona66/save-converter/emulator"
+)
+
+const (
+	PageSize        = 256
+	PagesPerPak     = emulator.MempakSize / PageSize // 128
+	HeaderPage      = 0
+	TOCPage1        = 1
+	TOCPage2        = 2
+	NoteTableOffset = 3 * PageSize // 0x0300
+	NoteEntrySize   = 32
+	NumNotes        = 16
+	FirstDataPage   = 5
+	NumDataPages    = PagesPerPak - FirstDataPage // 123
+
+	linkFree = 0x0000 // page-link value for an unused page
+	linkLast = 0x0001 // page-link value marking the last page of a note
+
+	// linkReserved marks the TOC entries for pages 0-4 (the header page,
+	// both TOC copies and the note table itself): real Controller Paks
+	// share linkLast's value for these so free-page scans never mistake
+	// the pak's own structures for allocatable space.
+	linkReserved = linkLast
+
+	headerIDOffset       = 0x20 // offset of the manufacturer/product ID block within the header page
+	headerChecksumOffset = 0x2C // offset of the header page's checksum byte
+)
+
+// manufacturerID is the fixed vendor/product identifier real Controller
+// Paks carry at headerIDOffset in their header page. Emulators that
+// validate page 0 before trusting the rest of the image (gopher64 among
+// them) use its presence, together with headerChecksum, to tell an
+// initialized pak from a blank or corrupted one.
+var manufacturerID = [4]byte{0x00, 0x00, 0x00, 0x01}
+
+// Note describes one in-use Controller Pak note, as found by List.
+type Note struct {
+	Pak           int // which 32KB sub-pak this note was found in, for multi-pak fullmempak files
+	Index         int // note slot (0-15) within that sub-pak's note directory
+	GameCode      string
+	PublisherCode string
+	Name          string
+	StartPage     int
+	PageCount     int
+}
+
+// List parses path (a 32KB Controller Pak, or a gopher64-style fullmempak
+// holding several of them back to back) and returns every in-use note it finds.
+func List(path string) ([]Note, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading Controller Pak file '%s': %w", path, err)
+	}
+
+	numPaks, err := paksIn(len(data))
+	if err != nil {
+		return nil, fmt.Errorf("Error: '%s': %w", path, err)
+	}
+
+	var notes []Note
+	for pak := 0; pak < numPaks; pak++ {
+		sub := data[pak*emulator.MempakSize : (pak+1)*emulator.MempakSize]
+		subNotes, err := listPak(sub)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing sub-pak %d of '%s': %w", pak, path, err)
+		}
+		for _, n := range subNotes {
+			n.Pak = pak
+			notes = append(notes, n)
+		}
+	}
+	return notes, nil
+}
+
+// Extract writes note noteIndex, as numbered in List's result order, to
+// outFile as a standalone .note blob: the note's data pages, concatenated
+// in on-disk chain order, with no extra header.
+func Extract(path string, noteIndex int, outFile string) error {
+	notes, err := List(path)
+	if err != nil {
+		return err
+	}
+	if noteIndex < 0 || noteIndex >= len(notes) {
+		return fmt.Errorf("Error: note index %d out of range (found %d notes in '%s')", noteIndex, len(notes), path)
+	}
+	note := notes[noteIndex]
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("Error reading Controller Pak file '%s': %w", path, err)
+	}
+	sub := data[note.Pak*emulator.MempakSize : (note.Pak+1)*emulator.MempakSize]
+	toc := sub[TOCPage1*PageSize : (TOCPage1+1)*PageSize]
+	pages, err := walkChain(toc, note.StartPage)
+	if err != nil {
+		return fmt.Errorf("Error walking page chain for note %d in '%s': %w", noteIndex, path, err)
+	}
+
+	out, err := os.Create(outFile)
+	if err != nil {
+		return fmt.Errorf("Error creating note output file '%s': %w", outFile, err)
+	}
+	defer out.Close()
+
+	for _, page := range pages {
+		if _, err := out.Write(sub[page*PageSize : (page+1)*PageSize]); err != nil {
+			return fmt.Errorf("Error writing note data to '%s': %w", outFile, err)
+		}
+	}
+	return out.Sync()
+}
+
+// Merge consolidates every note found across srcs (32KB Controller Paks)
+// into a single 128KB gopher64-style fullmempak written to dst, packing
+// notes in encounter order into the output's four 32KB sub-paks and
+// rebuilding each sub-pak's note directory and page-link chains from
+// scratch. It fails if the notes don't fit.
+func Merge(dst string, srcs ...string) error {
+	type noteData struct {
+		entry []byte
+		pages [][]byte
+	}
+
+	var allNotes []noteData
+	for _, src := range srcs {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return fmt.Errorf("Error reading Controller Pak file '%s': %w", src, err)
+		}
+		numPaks, err := paksIn(len(data))
+		if err != nil {
+			return fmt.Errorf("Error: '%s': %w", src, err)
+		}
+		for pak := 0; pak < numPaks; pak++ {
+			sub := data[pak*emulator.MempakSize : (pak+1)*emulator.MempakSize]
+			toc := sub[TOCPage1*PageSize : (TOCPage1+1)*PageSize]
+			for idx := 0; idx < NumNotes; idx++ {
+				entry := sub[NoteTableOffset+idx*NoteEntrySize : NoteTableOffset+(idx+1)*NoteEntrySize]
+				startPage := int(binary.BigEndian.Uint16(entry[0:2]))
+				if startPage == 0 {
+					continue
+				}
+				chainPages, err := walkChain(toc, startPage)
+				if err != nil {
+					return fmt.Errorf("Error reading note %d in '%s': %w", idx, src, err)
+				}
+				nd := noteData{entry: append([]byte(nil), entry...)}
+				for _, p := range chainPages {
+					nd.pages = append(nd.pages, append([]byte(nil), sub[p*PageSize:(p+1)*PageSize]...))
+				}
+				allNotes = append(allNotes, nd)
+			}
+		}
+	}
+
+	numPaks := emulator.FullMempakSize / emulator.MempakSize
+	out := make([]byte, emulator.FullMempakSize)
+	for pak := 0; pak < numPaks; pak++ {
+		initSubPak(out[pak*emulator.MempakSize : (pak+1)*emulator.MempakSize])
+	}
+	subPak, noteSlot, dataPage := 0, 0, FirstDataPage
+
+	for _, nd := range allNotes {
+		if noteSlot >= NumNotes || dataPage+len(nd.pages) > PagesPerPak {
+			subPak++
+			noteSlot, dataPage = 0, FirstDataPage
+		}
+		if subPak >= numPaks {
+			return fmt.Errorf("Error: %d notes do not fit in a %dKB fullmempak", len(allNotes), emulator.FullMempakSize/1024)
+		}
+
+		sub := out[subPak*emulator.MempakSize : (subPak+1)*emulator.MempakSize]
+		toc := sub[TOCPage1*PageSize : (TOCPage1+1)*PageSize]
+
+		startPage := dataPage
+		for i, page := range nd.pages {
+			copy(sub[dataPage*PageSize:(dataPage+1)*PageSize], page)
+			if i == len(nd.pages)-1 {
+				binary.BigEndian.PutUint16(toc[dataPage*2:dataPage*2+2], linkLast)
+			} else {
+				binary.BigEndian.PutUint16(toc[dataPage*2:dataPage*2+2], uint16(dataPage+1))
+			}
+			dataPage++
+		}
+
+		entry := sub[NoteTableOffset+noteSlot*NoteEntrySize : NoteTableOffset+(noteSlot+1)*NoteEntrySize]
+		copy(entry, nd.entry)
+		binary.BigEndian.PutUint16(entry[0:2], uint16(startPage))
+		setEntryChecksum(entry)
+		noteSlot++
+
+		copy(sub[TOCPage2*PageSize:(TOCPage2+1)*PageSize], toc)
+	}
+
+	if err := os.WriteFile(dst, out, 0o644); err != nil {
+		return fmt.Errorf("Error writing merged Controller Pak '%s': %w", dst, err)
+	}
+	return nil
+}
+
+// initSubPak resets sub (one 32KB Controller Pak within the output) to an
+// empty-but-valid state: a synthesized header page and both TOC copies with
+// pages 0-4 marked reserved, so a pak Merge doesn't place any notes into
+// still reads as initialized rather than blank/corrupt.
+func initSubPak(sub []byte) {
+	copy(sub[HeaderPage*PageSize:(HeaderPage+1)*PageSize], headerPage())
+
+	toc := sub[TOCPage1*PageSize : (TOCPage1+1)*PageSize]
+	for page := 0; page < FirstDataPage; page++ {
+		binary.BigEndian.PutUint16(toc[page*2:page*2+2], linkReserved)
+	}
+	copy(sub[TOCPage2*PageSize:(TOCPage2+1)*PageSize], toc)
+}
+
+// headerPage synthesizes a valid Controller Pak header page (page 0):
+// manufacturerID followed by its checksum.
+func headerPage() []byte {
+	page := make([]byte, PageSize)
+	copy(page[headerIDOffset:], manufacturerID[:])
+	page[headerChecksumOffset] = headerChecksum(page)
+	return page
+}
+
+// headerChecksum is the sum of a header page's bytes outside the checksum
+// byte itself, stored at headerChecksumOffset. Mirrors entryChecksum's
+// byte-sum scheme, applied to the whole header page instead of a note entry.
+func headerChecksum(page []byte) byte {
+	var sum byte
+	for i, b := range page {
+		if i == headerChecksumOffset {
+			continue
+		}
+		sum += b
+	}
+	return sum
+}
+
+func paksIn(size int) (int, error) {
+	if size <= 0 || size%emulator.MempakSize != 0 {
+		return 0, fmt.Errorf("not a whole number of %dKB Controller Pak images (got %d bytes)", emulator.MempakSize/1024, size)
+	}
+	return size / emulator.MempakSize, nil
+}
+
+func listPak(sub []byte) ([]Note, error) {
+	toc := sub[TOCPage1*PageSize : (TOCPage1+1)*PageSize]
+	var notes []Note
+	for idx := 0; idx < NumNotes; idx++ {
+		entry := sub[NoteTableOffset+idx*NoteEntrySize : NoteTableOffset+(idx+1)*NoteEntrySize]
+		startPage := int(binary.BigEndian.Uint16(entry[0:2]))
+		if startPage == 0 {
+			continue
+		}
+		pages, err := walkChain(toc, startPage)
+		if err != nil {
+			return nil, fmt.Errorf("note %d: %w", idx, err)
+		}
+		notes = append(notes, Note{
+			Index:         idx,
+			GameCode:      trimASCII(entry[4:8]),
+			PublisherCode: trimASCII(entry[8:10]),
+			Name:          decodeN64Charset(entry[10:26]),
+			StartPage:     startPage,
+			PageCount:     len(pages),
+		})
+	}
+	return notes, nil
+}
+
+// walkChain follows toc (a 128-entry page-link table) from start until it
+// hits the terminal marker, returning the data pages visited in chain order.
+func walkChain(toc []byte, start int) ([]int, error) {
+	var pages []int
+	visited := map[int]bool{}
+	page := start
+	for {
+		if page < FirstDataPage || page >= PagesPerPak {
+			return nil, fmt.Errorf("chain left valid data page range at page %d", page)
+		}
+		if visited[page] {
+			return nil, fmt.Errorf("chain loops at page %d", page)
+		}
+		visited[page] = true
+		pages = append(pages, page)
+
+		next := int(binary.BigEndian.Uint16(toc[page*2 : page*2+2]))
+		if next == linkLast {
+			return pages, nil
+		}
+		if next == linkFree {
+			return nil, fmt.Errorf("chain hits a free page at %d", page)
+		}
+		page = next
+	}
+}
+
+// entryChecksum is the sum of a 32-byte note entry's first 27 bytes, stored
+// at byte 27 to detect a corrupted note directory.
+func entryChecksum(entry []byte) byte {
+	var sum byte
+	for _, b := range entry[:27] {
+		sum += b
+	}
+	return sum
+}
+
+func setEntryChecksum(entry []byte) {
+	entry[27] = entryChecksum(entry)
+}
+
+// trimASCII converts a fixed-width ASCII field (game code, publisher code)
+// to a string, dropping null bytes and trailing spaces.
+func trimASCII(b []byte) string {
+	return strings.TrimRight(strings.Map(func(r rune) rune {
+		if r == 0 {
+			return -1
+		}
+		return r
+	}, string(b)), " ")
+}
+
+// decodeN64Charset decodes a note name's N64 font encoding into an ASCII
+// string, trimming trailing blanks. Only blanks (0x00, 0x0F) and the
+// alphanumeric subset of the font table are mapped; anything else renders
+// as '?'.
+func decodeN64Charset(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		switch {
+		case c == 0x00 || c == 0x0F:
+			sb.WriteByte(' ')
+		case c >= 0x10 && c <= 0x19:
+			sb.WriteByte('0' + (c - 0x10))
+		case c >= 0x1A && c <= 0x33:
+			sb.WriteByte('A' + (c - 0x1A))
+		default:
+			sb.WriteByte('?')
+		}
+	}
+	return strings.TrimRight(sb.String(), " ")
+}
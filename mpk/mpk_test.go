@@ -0,0 +1,80 @@
+package mpk
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lualona66/save-converter/emulator"
+)
+
+// buildTestPak returns a minimal but valid 32KB Controller Pak image holding
+// a single note with game code gameCode, starting at startPage.
+func buildTestPak(gameCode string, startPage int) []byte {
+	pak := make([]byte, emulator.MempakSize)
+
+	toc := pak[TOCPage1*PageSize : (TOCPage1+1)*PageSize]
+	binary.BigEndian.PutUint16(toc[startPage*2:startPage*2+2], linkLast)
+	copy(pak[TOCPage2*PageSize:(TOCPage2+1)*PageSize], toc)
+
+	entry := pak[NoteTableOffset : NoteTableOffset+NoteEntrySize]
+	binary.BigEndian.PutUint16(entry[0:2], uint16(startPage))
+	copy(entry[4:8], []byte(gameCode))
+	setEntryChecksum(entry)
+
+	copy(pak[startPage*PageSize:(startPage+1)*PageSize], []byte("note-data"))
+	return pak
+}
+
+// TestMergeWritesValidHeaderAndReservedPages guards against Merge shipping a
+// fullmempak whose header page and reserved TOC entries are left zeroed:
+// strict readers (gopher64 among them) treat that as an uninitialized pak
+// and may offer to reformat it, destroying the notes Merge just consolidated.
+func TestMergeWritesValidHeaderAndReservedPages(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.pak")
+	if err := os.WriteFile(src, buildTestPak("GAME", FirstDataPage), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(dir, "out.mpk")
+	if err := Merge(dst, src); err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+
+	out, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	numPaks := emulator.FullMempakSize / emulator.MempakSize
+	for pak := 0; pak < numPaks; pak++ {
+		sub := out[pak*emulator.MempakSize : (pak+1)*emulator.MempakSize]
+
+		header := sub[HeaderPage*PageSize : (HeaderPage+1)*PageSize]
+		if got := header[headerChecksumOffset]; got != headerChecksum(header) {
+			t.Errorf("sub-pak %d: header checksum %#02x does not match its own bytes", pak, got)
+		}
+		for i, b := range manufacturerID {
+			if header[headerIDOffset+i] != b {
+				t.Errorf("sub-pak %d: manufacturer ID byte %d = %#02x, want %#02x", pak, i, header[headerIDOffset+i], b)
+			}
+		}
+
+		toc := sub[TOCPage1*PageSize : (TOCPage1+1)*PageSize]
+		for page := 0; page < FirstDataPage; page++ {
+			if link := binary.BigEndian.Uint16(toc[page*2 : page*2+2]); link == linkFree {
+				t.Errorf("sub-pak %d: reserved page %d still marked free (0x0000)", pak, page)
+			}
+		}
+	}
+
+	notes, err := List(dst)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(notes) != 1 || notes[0].GameCode != "GAME" {
+		t.Fatalf("List after Merge = %+v, want one note with GameCode \"GAME\"", notes)
+	}
+}
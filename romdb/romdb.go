@@ -0,0 +1,111 @@
+// Package romdb loads Logiqx/No-Intro style DAT files and indexes the
+// contained game entries by CRC32, MD5 and SHA1 so a ROM can be identified
+// by whichever hash the caller already has on hand.
+package romdb
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Game is a single ROM entry from a DAT file.
+type Game struct {
+	Name  string
+	Size  int64
+	CRC32 string
+	MD5   string
+	SHA1  string
+}
+
+// DB is a DAT file indexed by hash for fast lookup.
+type DB struct {
+	byCRC32 map[string]Game
+	byMD5   map[string]Game
+	bySHA1  map[string]Game
+}
+
+// datafile mirrors the subset of the Logiqx/No-Intro DAT XML schema used
+// for identification: a flat list of <game> elements, each with one or more
+// <rom> children carrying the hashes.
+type datafile struct {
+	Games []struct {
+		Name string `xml:"name,attr"`
+		Roms []struct {
+			Name string `xml:"name,attr"`
+			Size string `xml:"size,attr"`
+			CRC  string `xml:"crc,attr"`
+			MD5  string `xml:"md5,attr"`
+			SHA1 string `xml:"sha1,attr"`
+		} `xml:"rom"`
+	} `xml:"game"`
+}
+
+// Load reads and indexes a Logiqx/No-Intro DAT XML file.
+func Load(path string) (*DB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error opening DAT file: %w", err)
+	}
+	defer f.Close()
+
+	var parsed datafile
+	if err := xml.NewDecoder(f).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("Error parsing DAT file '%s': %w", path, err)
+	}
+
+	db := &DB{
+		byCRC32: make(map[string]Game),
+		byMD5:   make(map[string]Game),
+		bySHA1:  make(map[string]Game),
+	}
+
+	for _, g := range parsed.Games {
+		for _, r := range g.Roms {
+			size, _ := strconv.ParseInt(r.Size, 10, 64)
+			game := Game{
+				Name:  g.Name,
+				Size:  size,
+				CRC32: strings.ToUpper(r.CRC),
+				MD5:   strings.ToUpper(r.MD5),
+				SHA1:  strings.ToUpper(r.SHA1),
+			}
+			if game.CRC32 != "" {
+				db.byCRC32[game.CRC32] = game
+			}
+			if game.MD5 != "" {
+				db.byMD5[game.MD5] = game
+			}
+			if game.SHA1 != "" {
+				db.bySHA1[game.SHA1] = game
+			}
+		}
+	}
+
+	return db, nil
+}
+
+// Lookup finds the game matching any of the given hashes, preferring
+// CRC32, then MD5, then SHA1. Hashes are matched case-insensitively;
+// empty hashes are ignored. The second return value reports whether a
+// match was found.
+func (db *DB) Lookup(crc32Hex, md5Hex, sha1Hex string) (Game, bool) {
+	if crc32Hex != "" {
+		if g, ok := db.byCRC32[strings.ToUpper(crc32Hex)]; ok {
+			return g, true
+		}
+	}
+	if md5Hex != "" {
+		if g, ok := db.byMD5[strings.ToUpper(md5Hex)]; ok {
+			return g, true
+		}
+	}
+	if sha1Hex != "" {
+		if g, ok := db.bySHA1[strings.ToUpper(sha1Hex)]; ok {
+			return g, true
+		}
+	}
+	return Game{}, false
+}
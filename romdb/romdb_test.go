@@ -0,0 +1,63 @@
+package romdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestDAT(t *testing.T) string {
+	t.Helper()
+	dat := `<datafile>
+  <game name="Game One">
+    <rom name="one.z64" size="4096" crc="AABBCCDD" md5="00112233445566778899aabbccddeeff" sha1="0011223344556677889900112233445566778899"/>
+  </game>
+  <game name="Game Two">
+    <rom name="two.z64" size="8192" crc="11223344" md5="" sha1=""/>
+  </game>
+</datafile>`
+
+	path := filepath.Join(t.TempDir(), "test.dat")
+	if err := os.WriteFile(path, []byte(dat), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadAndLookup(t *testing.T) {
+	db, err := Load(writeTestDAT(t))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	game, ok := db.Lookup("aabbccdd", "", "")
+	if !ok {
+		t.Fatal("Lookup by CRC32 (lowercase) found nothing")
+	}
+	if game.Name != "Game One" {
+		t.Errorf("Lookup by CRC32 = %q, want %q", game.Name, "Game One")
+	}
+
+	if _, ok := db.Lookup("", "00112233445566778899AABBCCDDEEFF", ""); !ok {
+		t.Error("Lookup by MD5 found nothing")
+	}
+
+	if _, ok := db.Lookup("", "", "0011223344556677889900112233445566778899"); !ok {
+		t.Error("Lookup by SHA1 found nothing")
+	}
+
+	// CRC32 takes precedence over MD5 when both are supplied and point at
+	// different games.
+	game, ok = db.Lookup("11223344", "00112233445566778899aabbccddeeff", "")
+	if !ok || game.Name != "Game Two" {
+		t.Errorf("Lookup with conflicting hashes = %+v, %v, want Game Two (CRC32 wins)", game, ok)
+	}
+
+	if _, ok := db.Lookup("deadbeef", "", ""); ok {
+		t.Error("Lookup matched a hash that isn't in the DAT")
+	}
+
+	if _, ok := db.Lookup("", "", ""); ok {
+		t.Error("Lookup with no hashes should never match")
+	}
+}